@@ -16,7 +16,11 @@ require github.com/gobwas/glob v0.2.2
 
 require github.com/zalando/go-keyring v0.2.6
 
-require golang.org/x/term v0.31.0
+require (
+	filippo.io/age v1.2.1
+	golang.org/x/term v0.31.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	al.essio.dev/pkg/shellescape v1.5.1 // indirect
@@ -27,6 +31,7 @@ require (
 	github.com/mattn/go-runewidth v0.0.9 // indirect
 	github.com/mattn/go-tty v0.0.3 // indirect
 	github.com/pkg/term v1.2.0-beta.2 // indirect
+	golang.org/x/crypto v0.31.0 // indirect
 	golang.org/x/net v0.33.0 // indirect
 	golang.org/x/sys v0.32.0 // indirect
 )
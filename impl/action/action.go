@@ -1 +1,339 @@
 package action
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/BurntSushi/toml"
+	"github.com/gobwas/glob"
+	"github.com/thoas/go-funk"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ACTIONS_DIR = ".microbox/actions"
+
+	// Manifest of action directory, script is named by Run
+	MANIFEST_NAME = "action"
+	DEFAULT_RUN   = "run"
+
+	STRING = "string"
+	INT    = "int"
+	BOOL   = "bool"
+	CHOICE = "choice"
+)
+
+var Types = []string{STRING, INT, BOOL, CHOICE}
+
+var manifestExtensions = []string{".toml", ".yaml", ".yml"}
+
+// Parameter of action, given to script as argument in declared order
+type Param struct {
+	Name        string      `yaml:"Name"`
+	Type        string      `yaml:"Type"`
+	Description string      `yaml:"Description"`
+	Default     interface{} `yaml:"Default"`
+	Required    bool        `yaml:"Required"`
+	Pattern     string      `yaml:"Pattern"`
+	Choices     []string    `yaml:"Choices"`
+}
+
+// Action script, with optional manifest declaring its parameters and templates of branch, commit and review
+type Action struct {
+	Name          string  `toml:"-" yaml:"-"`
+	Path          string  `toml:"-" yaml:"-"`
+	Manifest      string  `toml:"-" yaml:"-"`
+	Description   string  `yaml:"Description"`
+	AppliesTo     string  `yaml:"AppliesTo"`
+	Branch        string  `yaml:"Branch"`
+	CommitMessage string  `yaml:"CommitMessage"`
+	ReviewTitle   string  `yaml:"ReviewTitle"`
+	ReviewMessage string  `yaml:"ReviewMessage"`
+	Run           string  `yaml:"Run"`
+	Params        []Param `yaml:"Params"`
+
+	appliesTo glob.Glob
+}
+
+// Return actions directory of workspace
+func Dir(workspaceDir string) string {
+	return filepath.Join(workspaceDir, ACTIONS_DIR)
+}
+
+// Return all actions of directory, sorted by name
+// Actions are executable files, files with a manifest and directories with a manifest, other files are ignored ( ex: README.md )
+// Invalid actions don't prevent listing the others, their errors are joined
+func List(dir string) ([]*Action, error) {
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var actions []*Action
+	var errs []error
+	for _, entry := range entries {
+		if !isAction(dir, entry) {
+			continue
+		}
+
+		action, err := Load(dir, entry.Name())
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		actions = append(actions, action)
+	}
+
+	return actions, errors.Join(errs...)
+}
+
+// Return true if directory entry is an action
+func isAction(dir string, entry os.DirEntry) bool {
+
+	path := filepath.Join(dir, entry.Name())
+
+	switch {
+	case strings.HasPrefix(entry.Name(), "."), funk.ContainsString(manifestExtensions, filepath.Ext(entry.Name())):
+		return false
+	case entry.IsDir():
+		return findManifest(filepath.Join(path, MANIFEST_NAME)) != ""
+	case findManifest(path) != "":
+		return true
+	}
+
+	info, err := entry.Info()
+	return err == nil && info.Mode().IsRegular() && info.Mode().Perm()&0111 != 0
+}
+
+// Load action from directory, manifest is <name>.toml / <name>.yaml next to script, or action.toml / action.yaml in action directory
+func Load(dir string, name string) (*Action, error) {
+
+	path := filepath.Join(dir, name)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("action '%s' not found", name)
+	}
+
+	action := &Action{}
+	manifest := findManifest(path)
+	if info.IsDir() {
+		manifest = findManifest(filepath.Join(path, MANIFEST_NAME))
+	}
+
+	if manifest != "" {
+		if err := decodeManifest(manifest, action); err != nil {
+			return nil, fmt.Errorf("invalid manifest '%s' ( %s )", manifest, err.Error())
+		}
+	}
+
+	action.Name = name
+	action.Path = path
+	action.Manifest = manifest
+
+	if info.IsDir() {
+		run := action.Run
+		if run == "" {
+			run = DEFAULT_RUN
+		}
+		action.Path = filepath.Join(path, run)
+	}
+
+	if err := action.validate(); err != nil {
+		return nil, fmt.Errorf("invalid manifest '%s' ( %s )", manifest, err.Error())
+	}
+
+	return action, nil
+}
+
+// Return true if action applies to repository folder
+func (a *Action) Applies(folder string) bool {
+	return a.appliesTo == nil || a.appliesTo.Match(folder)
+}
+
+// Return parameter values from arguments, given as name=value or by position
+func (a *Action) ParseArgs(args []string) (map[string]string, error) {
+
+	values := map[string]string{}
+	var positional []string
+
+	for _, arg := range args {
+		if name, value, found := strings.Cut(arg, "="); found && a.param(name) != nil {
+			values[name] = value
+			continue
+		}
+		positional = append(positional, arg)
+	}
+
+	// Positional arguments are given to parameters not set by name, in declared order
+	position := 0
+	for _, arg := range positional {
+		for position < len(a.Params) {
+			if _, set := values[a.Params[position].Name]; !set {
+				break
+			}
+			position++
+		}
+		if position >= len(a.Params) {
+			return nil, fmt.Errorf("unexpected argument '%s'", arg)
+		}
+		values[a.Params[position].Name] = arg
+		position++
+	}
+
+	return values, nil
+}
+
+// Return script arguments in declared order, defaults are applied and values are validated
+func (a *Action) Args(values map[string]string) ([]string, error) {
+
+	var args []string
+	for _, param := range a.Params {
+		value := values[param.Name]
+		if value == "" {
+			value = param.DefaultValue()
+		}
+		if err := param.Validate(value); err != nil {
+			return nil, err
+		}
+		values[param.Name] = value
+		args = append(args, value)
+	}
+
+	return args, nil
+}
+
+// Render template ( ex: Branch ) with parameter values ( ex: bump-{{.version}} )
+func (a *Action) Render(text string, values map[string]string) (string, error) {
+
+	tmpl, err := template.New(a.Name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, values); err != nil {
+		return "", err
+	}
+
+	return out.String(), nil
+}
+
+// Return default value of parameter as text, empty if none
+func (p Param) DefaultValue() string {
+	if p.Default == nil {
+		return ""
+	}
+	return fmt.Sprint(p.Default)
+}
+
+// Return error if value is not valid for parameter
+func (p Param) Validate(value string) error {
+
+	if value == "" {
+		if p.Required {
+			return fmt.Errorf("parameter '%s' is required", p.Name)
+		}
+		return nil
+	}
+
+	switch p.Type {
+	case INT:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("parameter '%s' must be an integer", p.Name)
+		}
+	case BOOL:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("parameter '%s' must be a boolean", p.Name)
+		}
+	case CHOICE:
+		if !funk.ContainsString(p.Choices, value) {
+			return fmt.Errorf("parameter '%s' must be one of %s", p.Name, strings.Join(p.Choices, ", "))
+		}
+	}
+
+	if p.Pattern != "" && !regexp.MustCompile(p.Pattern).MatchString(value) {
+		return fmt.Errorf("parameter '%s' must match '%s'", p.Name, p.Pattern)
+	}
+
+	return nil
+}
+
+// Check manifest content, and compile applicability glob
+func (a *Action) validate() error {
+
+	if a.AppliesTo != "" {
+		appliesTo, err := glob.Compile(a.AppliesTo)
+		if err != nil {
+			return fmt.Errorf("invalid AppliesTo '%s'", a.AppliesTo)
+		}
+		a.appliesTo = appliesTo
+	}
+
+	for i := range a.Params {
+		param := &a.Params[i]
+		if param.Name == "" {
+			return errors.New("parameter name is required")
+		}
+		if param.Type == "" {
+			param.Type = STRING
+		}
+		if !funk.ContainsString(Types, param.Type) {
+			return fmt.Errorf("type '%s' of parameter '%s' is invalid, expected one of %s", param.Type, param.Name, strings.Join(Types, ", "))
+		}
+		if param.Type == CHOICE && len(param.Choices) == 0 {
+			return fmt.Errorf("choices of parameter '%s' are required", param.Name)
+		}
+		if _, err := regexp.Compile(param.Pattern); err != nil {
+			return fmt.Errorf("pattern of parameter '%s' is invalid", param.Name)
+		}
+		if defaultValue := param.DefaultValue(); defaultValue != "" {
+			if err := param.Validate(defaultValue); err != nil {
+				return fmt.Errorf("default value is invalid ( %s )", err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+func (a *Action) param(name string) *Param {
+	for i := range a.Params {
+		if a.Params[i].Name == name {
+			return &a.Params[i]
+		}
+	}
+	return nil
+}
+
+// Return first existing manifest for path without extension, empty if none
+func findManifest(path string) string {
+	for _, extension := range manifestExtensions {
+		if _, err := os.Stat(path + extension); err == nil {
+			return path + extension
+		}
+	}
+	return ""
+}
+
+func decodeManifest(path string, action *Action) error {
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if filepath.Ext(path) == ".toml" {
+		_, err = toml.Decode(string(content), action)
+		return err
+	}
+
+	return yaml.Unmarshal(content, action)
+}
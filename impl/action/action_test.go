@@ -0,0 +1,145 @@
+package action
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const bumpManifest = `
+Description = "Bump library version"
+AppliesTo = "services/*"
+Branch = "bump-{{.library}}-{{.version}}"
+CommitMessage = "Bump {{.library}} to {{.version}}"
+
+[[Params]]
+Name = "library"
+Type = "choice"
+Choices = ["spring-boot", "jackson"]
+Required = true
+
+[[Params]]
+Name = "version"
+Pattern = '^\d+\.\d+\.\d+$'
+Default = "3.2.0"
+`
+
+func writeAction(t *testing.T, dir string, name string, content string) {
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadWithManifest(t *testing.T) {
+
+	dir := t.TempDir()
+	writeAction(t, dir, "bump", "#!/bin/sh\n")
+	writeAction(t, dir, "bump.toml", bumpManifest)
+
+	action, err := Load(dir, "bump")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if action.Path != filepath.Join(dir, "bump") || len(action.Params) != 2 || action.Params[1].Type != STRING {
+		t.Errorf("unexpected action %+v", action)
+	}
+
+	if !action.Applies("services/api") || action.Applies("libs/core") {
+		t.Errorf("unexpected applicability")
+	}
+
+	values, err := action.ParseArgs([]string{"version=3.3.1", "jackson"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := action.Args(values)
+	if err != nil || len(args) != 2 || args[0] != "jackson" || args[1] != "3.3.1" {
+		t.Errorf("unexpected arguments %v ( %v )", args, err)
+	}
+
+	// Positional arguments skip parameters given by name
+	if values, err := action.ParseArgs([]string{"library=jackson", "3.3.1"}); err != nil || values["library"] != "jackson" || values["version"] != "3.3.1" {
+		t.Errorf("unexpected values %v ( %v )", values, err)
+	}
+	if _, err := action.ParseArgs([]string{"library=jackson", "3.3.1", "extra"}); err == nil {
+		t.Errorf("expected error for unexpected argument")
+	}
+
+	branch, err := action.Render(action.Branch, values)
+	if err != nil || branch != "bump-jackson-3.3.1" {
+		t.Errorf("unexpected branch '%s' ( %v )", branch, err)
+	}
+
+	if _, err := action.Args(map[string]string{"library": "guava"}); err == nil {
+		t.Errorf("expected error for invalid choice")
+	}
+	if _, err := action.Args(map[string]string{"library": "jackson", "version": "latest"}); err == nil {
+		t.Errorf("expected error for value not matching pattern")
+	}
+
+	values = map[string]string{"library": "jackson"}
+	if args, err := action.Args(values); err != nil || args[1] != "3.2.0" || values["version"] != "3.2.0" {
+		t.Errorf("expected default value, got %v ( %v )", args, err)
+	}
+}
+
+func TestLoadDirectoryAction(t *testing.T) {
+
+	dir := t.TempDir()
+	actionDir := filepath.Join(dir, "format")
+	if err := os.MkdirAll(actionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeAction(t, actionDir, "run", "#!/bin/sh\n")
+	writeAction(t, actionDir, "action.yaml", "Description: Format sources\nParams:\n  - Name: check\n    Type: bool\n    Default: false\n")
+	writeAction(t, dir, "cleanup", "#!/bin/sh\n")
+
+	// Documentation and hidden files are not actions
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Actions\n"), 0644) //nolint:errcheck
+	os.WriteFile(filepath.Join(dir, ".DS_Store"), nil, 0644)                   //nolint:errcheck
+	os.Mkdir(filepath.Join(dir, "lib"), 0755)                                  //nolint:errcheck
+
+	actions, err := List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(actions) != 2 || actions[0].Name != "cleanup" || actions[0].Manifest != "" {
+		t.Fatalf("unexpected actions %+v", actions)
+	}
+
+	format := actions[1]
+	if format.Path != filepath.Join(actionDir, "run") || format.Description != "Format sources" || format.Params[0].DefaultValue() != "false" {
+		t.Errorf("unexpected action %+v", format)
+	}
+}
+
+func TestLoadInvalidManifest(t *testing.T) {
+
+	dir := t.TempDir()
+	writeAction(t, dir, "bump", "#!/bin/sh\n")
+	writeAction(t, dir, "bump.toml", "[[Params]]\nName = \"count\"\nType = \"int\"\nDefault = \"many\"\n")
+
+	if _, err := Load(dir, "bump"); err == nil {
+		t.Errorf("expected error for invalid default value")
+	}
+
+	if _, err := Load(dir, "missing"); err == nil {
+		t.Errorf("expected error for missing action")
+	}
+
+	// Invalid manifests are all reported, without hiding valid actions
+	writeAction(t, dir, "clean", "#!/bin/sh\n")
+	writeAction(t, dir, "deploy", "#!/bin/sh\n")
+	writeAction(t, dir, "deploy.yaml", "Params: [")
+
+	actions, err := List(dir)
+	if len(actions) != 1 || actions[0].Name != "clean" {
+		t.Errorf("unexpected actions %+v", actions)
+	}
+	if err == nil || !strings.Contains(err.Error(), "bump") || !strings.Contains(err.Error(), "deploy") {
+		t.Errorf("expected errors of both invalid actions, got %v", err)
+	}
+}
@@ -4,8 +4,43 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 )
 
+const REDACTED = "********"
+
+// Secrets to redact from command output
+var (
+	secrets     []string
+	secretsLock sync.RWMutex
+)
+
+// Register secret to redact from command output
+func AddSecret(secret string) {
+
+	if secret == "" {
+		return
+	}
+
+	secretsLock.Lock()
+	defer secretsLock.Unlock()
+
+	secrets = append(secrets, secret)
+}
+
+// Replace registered secrets in text
+func Redact(text string) string {
+
+	secretsLock.RLock()
+	defer secretsLock.RUnlock()
+
+	for _, secret := range secrets {
+		text = strings.ReplaceAll(text, secret, REDACTED)
+	}
+
+	return text
+}
+
 func ExecCmd(cmdName string, cmdArgs []string) (string, error) {
 	return ExecCmdDir(cmdName, cmdArgs, "")
 }
@@ -22,9 +57,9 @@ func ExecCmdDir(cmdName string, cmdArgs []string, dir string) (string, error) {
 		cmd.Dir = dir
 	}
 	if cmdOut, err = cmd.CombinedOutput(); err != nil {
-		return string(cmdOut), err
+		return Redact(string(cmdOut)), err
 	}
-	return strings.TrimSuffix(string(cmdOut), "\n"), nil
+	return Redact(strings.TrimSuffix(string(cmdOut), "\n")), nil
 }
 
 func ExecAndOutCmd(cmdName string, cmdArgs []string) error {
@@ -34,5 +69,13 @@ func ExecAndOutCmd(cmdName string, cmdArgs []string) error {
 }
 
 func ErrorString(err string) string {
-	return strings.ReplaceAll(strings.TrimSuffix(err, "\n"), "\n", " ")
+	return Redact(strings.ReplaceAll(strings.TrimSuffix(err, "\n"), "\n", " "))
+}
+
+func ExecInteractiveCmd(cmdName string, cmdArgs []string) error {
+	cmd := exec.Command(cmdName, cmdArgs...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
 }
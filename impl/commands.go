@@ -25,7 +25,28 @@ func InitCommands(config config.Config) []*cli.Command {
 
 	Commands = funk.ToMap([]cli.Command{
 		{Name: "init", Usage: "init workspace in current folder", Action: git.Init},
-		{Name: "authenticate", Usage: "refresh authentication token", Action: git.Auth},
+		{Name: "authenticate", Usage: "refresh authentication token", Flags: []cli.Flag{
+			remoteFlag(),
+			&cli.BoolFlag{
+				Name:  "device",
+				Usage: "Login with OAuth device flow ( GitHub, GitLab )",
+			},
+		}, Action: git.Auth},
+		{Name: "doctor", Usage: "check workspace health ( tools, config, remotes, actions ) and suggest fixes", Action: gitCommands.Doctor},
+		{Name: "credential", Usage: "act as GIT credential helper, providing token of remotes", ArgsUsage: "get|store|erase", Action: credentialCommand},
+		{Name: "config", Usage: "manage workspace settings", Commands: []*cli.Command{
+			{Name: "get", Usage: "display value of a setting", ArgsUsage: "key", Action: git.ConfigGet},
+			{Name: "set", Usage: "set value of a setting in workspace config", ArgsUsage: "key value", Action: git.ConfigSet},
+			{Name: "unset", Usage: "remove a setting from workspace config", ArgsUsage: "key", Action: git.ConfigUnset},
+			{Name: "list", Usage: "list all effective settings", Action: git.ConfigList},
+			{Name: "edit", Usage: "edit workspace config with $EDITOR", Action: git.ConfigEdit},
+			{Name: "validate", Usage: "validate effective settings", Action: git.ConfigValidate},
+		}},
+		{Name: "group", Usage: "manage " + labels.GroupsLabel + " of workspace", Commands: []*cli.Command{
+			{Name: "list", Usage: "list available " + labels.GroupsLabel + " and the ones selected in workspace", Flags: []cli.Flag{remoteFlag()}, Action: gitCommands.GroupList},
+			{Name: "add", Usage: "add " + labels.GroupsLabel + " to workspace", ArgsUsage: "[" + labels.GroupLabel + "...]", Flags: []cli.Flag{remoteFlag()}, Action: gitCommands.GroupAdd},
+			{Name: "remove", Usage: "remove " + labels.GroupsLabel + " from workspace", ArgsUsage: "[" + labels.GroupLabel + "...]", Flags: []cli.Flag{remoteFlag()}, Action: gitCommands.GroupRemove},
+		}},
 		{Name: "list", Usage: "list projects on workspace", Action: gitCommands.ListLocal},
 
 		{Name: "glist", Usage: "list all remote " + labels.RepositoriesLabel + " from " + labels.GroupsLabel, Action: gitCommands.ListRemote},
@@ -35,6 +56,11 @@ func InitCommands(config config.Config) []*cli.Command {
 				Aliases: []string{"e"},
 				Usage:   "Pattern to exclude",
 			},
+			&cli.IntFlag{
+				Name:    "jobs",
+				Aliases: []string{"j"},
+				Usage:   "Number of parallel jobs ( default: config or number of CPU )",
+			},
 		}, Action: gitCommands.Clone},
 		{Name: "gup", Usage: "git pull + rebase all local " + labels.RepositoriesLabel, ArgsUsage: "[glob]", Flags: []cli.Flag{
 			&cli.BoolFlag{
@@ -42,10 +68,26 @@ func InitCommands(config config.Config) []*cli.Command {
 				Aliases: []string{"s"},
 				Usage:   "Enable autostash before pull",
 			},
+			&cli.IntFlag{
+				Name:    "jobs",
+				Aliases: []string{"j"},
+				Usage:   "Number of parallel jobs ( default: config or number of CPU )",
+			},
 		}, Action: gitCommands.Up},
-		{Name: "gst", Usage: "show git status for all local " + labels.RepositoriesLabel, ArgsUsage: "[glob]", Action: gitCommands.St},
-		{Name: "ggadd", Usage: "create new " + labels.GroupLabel, ArgsUsage: labels.CreateGroupUsage, Action: gitCommands.AddGroup},
+		{Name: "gst", Usage: "show git status for all local " + labels.RepositoriesLabel, ArgsUsage: "[glob]", Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "only",
+				Usage: "Show only " + labels.RepositoriesLabel + " in given state ( dirty | behind | ahead | diverged )",
+			},
+			&cli.IntFlag{
+				Name:    "jobs",
+				Aliases: []string{"j"},
+				Usage:   "Number of parallel jobs ( default: config or number of CPU )",
+			},
+		}, Action: gitCommands.St},
+		{Name: "ggadd", Usage: "create new " + labels.GroupLabel, ArgsUsage: labels.CreateGroupUsage, Flags: []cli.Flag{remoteFlag()}, Action: gitCommands.AddGroup},
 		{Name: "gadd", Usage: "create new " + labels.RepositoryLabel, ArgsUsage: labels.CreateRepositoryUsage, Flags: []cli.Flag{
+			remoteFlag(),
 			&cli.BoolFlag{
 				Name:    "init",
 				Aliases: []string{"i"},
@@ -69,7 +111,20 @@ func InitCommands(config config.Config) []*cli.Command {
 		}, Action: gitCommands.Add},
 		{Name: "ginit", Usage: "initialize " + labels.RepositoryLabel + " with Initializr", ArgsUsage: "repo type name dependencies", Action: gitCommands.Init},
 
-		{Name: "exec", Usage: "execute script / action on " + labels.RepositoryLabel + "", ArgsUsage: "[glob] [action]", Flags: []cli.Flag{
+		{Name: "exec", Usage: "execute script / action on " + labels.RepositoryLabel + "", ArgsUsage: "[glob] [action] [param...]", Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "list",
+				Aliases: []string{"l"},
+				Usage:   "List available actions",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Execute action in a temporary worktree and show resulting diff, nothing is committed, pushed or reviewed",
+			},
+			&cli.StringFlag{
+				Name:  "resume",
+				Usage: "Resume campaign by id, each repository continues from its last step",
+			},
 			&cli.BoolFlag{
 				Name:    "interactive",
 				Aliases: []string{"i"},
@@ -105,8 +160,31 @@ func InitCommands(config config.Config) []*cli.Command {
 				Aliases: []string{"rd"},
 				Usage:   "Submit " + labels.CodeReviewRequest + " as draft",
 			},
+			&cli.IntFlag{
+				Name:    "jobs",
+				Aliases: []string{"j"},
+				Usage:   "Number of parallel jobs to clone " + labels.RepositoriesLabel + " ( default: config or number of CPU )",
+			},
 		}, Action: gitCommands.Exec},
 
+		{Name: "campaign", Usage: "follow " + labels.CodeReviewRequest + "s created by exec campaigns", Commands: []*cli.Command{
+			{Name: "status", Usage: "display state, mergeability, checks and approvals of " + labels.CodeReviewRequest + "s of campaign", ArgsUsage: "id", Action: gitCommands.CampaignStatus},
+			{Name: "merge", Usage: "merge open " + labels.CodeReviewRequest + "s of campaign", ArgsUsage: "id", Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "strategy",
+					Aliases: []string{"s"},
+					Usage:   "Merge strategy ( merge | squash | rebase )",
+				},
+				&cli.BoolFlag{
+					Name:  "only-green",
+					Usage: "Merge only " + labels.CodeReviewRequest + " with successful checks, known to be mergeable and approved",
+				},
+				deleteBranchFlag(),
+			}, Action: gitCommands.CampaignMerge},
+			{Name: "close", Usage: "close open " + labels.CodeReviewRequest + "s of campaign", ArgsUsage: "id", Flags: []cli.Flag{deleteBranchFlag()}, Action: gitCommands.CampaignClose},
+			{Name: "rebase", Usage: "update source branch of open " + labels.CodeReviewRequest + "s of campaign with target branch", ArgsUsage: "id", Action: gitCommands.CampaignRebase},
+		}},
+
 		{Name: "shell", Usage: "Enter in interactive shell mode", Action: displayPrompt},
 		{Name: "exit", Usage: "exit the prompt", Action: exitCommand},
 		{Name: "clear", Usage: "clear the screen", Action: clearCommand},
@@ -115,6 +193,29 @@ func InitCommands(config config.Config) []*cli.Command {
 	return Commands.GetCliCmdArray()
 }
 
+// Return commands available when config cannot be loaded
+func InitRecoveryCommands(err error) []*cli.Command {
+	return []*cli.Command{
+		{Name: "doctor", Usage: "check workspace health ( tools, config, remotes, actions ) and suggest fixes", Action: git.DoctorConfigError(err)},
+	}
+}
+
+// Flag selecting remote of workspace, for commands acting on a single remote
+func remoteFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "remote",
+		Usage: "Name of remote to use ( default: first remote of workspace )",
+	}
+}
+
+func deleteBranchFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:    "delete-branch",
+		Aliases: []string{"d"},
+		Usage:   "Delete source branch of review request",
+	}
+}
+
 func (c *CliCommands) GetCliCmdArray() []*cli.Command {
 
 	commands := funk.Values(c).([]cli.Command)
@@ -127,6 +228,10 @@ func (c *CliCommands) GetCliCmdArray() []*cli.Command {
 	return funk.Map(commands, func(c cli.Command) *cli.Command { return &c }).([]*cli.Command)
 }
 
+func credentialCommand(_ context.Context, c *cli.Command) error {
+	return git.CredentialHelper(c.Args().Get(0), os.Stdin, os.Stdout)
+}
+
 func exitCommand(_ context.Context, c *cli.Command) error {
 	os.Exit(0)
 	return nil
@@ -3,32 +3,47 @@ package config
 import (
 	"bufio"
 	"errors"
-	"log"
+	"fmt"
 	"net/url"
 	"os"
 	"os/user"
 	"path/filepath"
-	"strings"
+	"sort"
 
 	"github.com/BurntSushi/toml"
-	"github.com/zalando/go-keyring"
+	"github.com/vanroy/microcli/impl/secret"
 )
 
 const CONFIG_FILE = ".microbox/config.toml"
-const KEYCHAIN_APP_PREFIX = "Microbox - "
+const USER_CONFIG_FILE = "microbox/config.toml"
+const SECRETS_FILE = ".microbox/secrets.age"
+const DEFAULT_REMOTE = "default"
+
+// Name of secrets stored for a remote, besides its token
+const (
+	REFRESH_TOKEN_SECRET = "refresh-token"
+	// Installation tokens of GitHub App are stored by owner ( ex: app-token/acme )
+	APP_TOKEN_SECRET = "app-token"
+)
+
+// Returned by Load, with loaded config, when a token cannot be retrieved from secret store
+var ErrSecret = errors.New("cannot retrieve token from secret store")
 
 var Options = GlobalOptions{
 	Interactive: true,
 	Verbose:     true,
+	Output:      "table",
 }
 
 type GlobalOptions struct {
 	Verbose     bool
 	Interactive bool
+	Output      string
 }
 
 type Config struct {
 	Git        GitConfig
+	Remotes    map[string]*GitConfig
 	Initializr InitializrConfig
 }
 
@@ -42,132 +57,352 @@ type GitConfig struct {
 	NormalizeName           bool
 	CloneProtocol           string
 	UseTokenForOperation    bool
+	SecretStore             string
+	SecretEnv               string
+	SecretCommand           string
+	OAuthClientId           string
+	AppId                   string
+	AppPrivateKeyPath       string
+	Jobs                    int
+	KeysetPagination        bool
+	IncludeSubgroups        bool
+	Layout                  string
 }
 
 type InitializrConfig struct {
 	Url string
 }
 
+// Return true if workspace is configured, by workspace config file or environment variables ( MBX_GIT_TYPE )
 func Exist() (bool, error) {
 
+	if os.Getenv(ENV_PREFIX+"GIT_TYPE") != "" {
+		return true, nil
+	}
+
 	configFile, err := getConfigFile()
 	if err != nil {
 		return false, err
 	}
 
-	if _, err := os.Stat(configFile); os.IsNotExist(err) {
-		return false, nil
-	} else if err != nil {
-		return false, err
-	} else {
-		return true, nil
-	}
+	return fileExist(configFile)
 }
 
+// Load config, merging in order of precedence : environment variables, workspace config and user config
+// If a token cannot be retrieved, config is returned with an error wrapping ErrSecret
 func Load() (*Config, error) {
 
-	if exist, err := Exist(); !exist {
+	conf, err := LoadSettings()
+	if conf == nil {
 		return nil, err
 	}
 
-	configFile, _ := getConfigFile()
+	var secretErrors []error
+
+	// Git section can be left empty when workspace only use named remotes
+	if len(conf.Git.Type) != 0 || len(conf.Remotes) == 0 {
+		secretErrors = append(secretErrors, ApplySecret(&conf.Git))
+	}
+	for _, name := range conf.RemoteNames() {
+		if name != DEFAULT_REMOTE {
+			secretErrors = append(secretErrors, ApplySecret(conf.Remotes[name]))
+		}
+	}
+
+	return conf, errors.Join(secretErrors...)
+}
+
+// Load config like Load, without retrieving tokens from secret stores
+func LoadSettings() (*Config, error) {
+
+	if exist, err := Exist(); !exist {
+		return nil, err
+	}
 
 	var conf Config
 
-	if _, err := toml.DecodeFile(configFile, &conf); err != nil {
+	// Keys defined in a file override keys decoded from previous one
+	for _, configFile := range []string{getUserConfigFile(), mustGetConfigFile()} {
+		if exist, _ := fileExist(configFile); !exist {
+			continue
+		}
+		if _, err := toml.DecodeFile(configFile, &conf); err != nil {
+			return nil, fmt.Errorf("cannot read '%s' ( %s )", configFile, err.Error())
+		}
+	}
+
+	if err := applyEnv(&conf); err != nil {
 		return nil, err
 	}
 
-	// Default to PAT auth mode
-	if len(conf.Git.AuthMode) == 0 {
-		conf.Git.AuthMode = "pat"
+	if len(conf.Git.Type) != 0 || len(conf.Remotes) == 0 {
+		applyDefaults(&conf.Git)
+	}
+	for _, name := range conf.RemoteNames() {
+		if name != DEFAULT_REMOTE {
+			applyDefaults(conf.Remotes[name])
+		}
 	}
 
-	// Retrieve password from keychain
-	if len(conf.Git.PrivateToken) == 0 && conf.Git.AuthMode == "pat" {
-		conf.Git.PrivateToken = getPassword(conf.Git)
+	return &conf, nil
+}
+
+func applyDefaults(conf *GitConfig) {
+
+	// Default to PAT auth mode
+	if len(conf.AuthMode) == 0 {
+		conf.AuthMode = "pat"
 	}
 
 	// Default to SSH clone protocol
-	if len(conf.Git.CloneProtocol) == 0 {
-		conf.Git.CloneProtocol = "ssh"
+	if len(conf.CloneProtocol) == 0 {
+		conf.CloneProtocol = "ssh"
 	}
+}
 
-	return &conf, nil
+// Retrieve token of remote from its secret store, if not already defined
+func ApplySecret(conf *GitConfig) error {
+
+	if len(conf.PrivateToken) == 0 && (conf.AuthMode == "pat" || conf.AuthMode == "oauth") {
+		token, err := getPassword(*conf)
+		if err != nil {
+			return fmt.Errorf("%w of %s ( %s )", ErrSecret, conf.BaseUrl, err.Error())
+		}
+		conf.PrivateToken = token
+	}
+
+	return nil
 }
 
-func Save(config Config) {
+// Return names of remotes, the default one ( Git section ) first if configured, then named remotes sorted
+func (c Config) RemoteNames() []string {
 
-	configFile, _ := getConfigFile()
+	var names []string
+	if len(c.Git.Type) != 0 || len(c.Remotes) == 0 {
+		names = append(names, DEFAULT_REMOTE)
+	}
+
+	var named []string
+	for name := range c.Remotes {
+		named = append(named, name)
+	}
+	sort.Strings(named)
+
+	return append(names, named...)
+}
+
+// Return config of a remote, with remote settings in Git section ( workspace settings are kept from Git section )
+func (c Config) ForRemote(name string) Config {
+
+	remoteConf := c.Copy()
+	if remote, ok := c.Remotes[name]; ok && name != DEFAULT_REMOTE {
+		remoteConf.Git = *remote
+		remoteConf.Git.GroupIds = append([]string{}, remote.GroupIds...)
+		remoteConf.Git.Jobs = c.Git.Jobs
+		remoteConf.Git.Layout = c.Git.Layout
+	}
+
+	return remoteConf
+}
+
+// Return a deep copy of config
+func (c Config) Copy() Config {
 
-	dir := filepath.Dir(configFile)
-	err := os.MkdirAll(dir, 0755)
+	copied := c
+	copied.Git.GroupIds = append([]string{}, c.Git.GroupIds...)
+
+	if c.Remotes != nil {
+		copied.Remotes = map[string]*GitConfig{}
+		for name, remote := range c.Remotes {
+			remoteCopy := *remote
+			remoteCopy.GroupIds = append([]string{}, remote.GroupIds...)
+			copied.Remotes[name] = &remoteCopy
+		}
+	}
+
+	return copied
+}
+
+// Save workspace config, tokens are saved in secret store of each remote
+func Save(config Config) error {
+
+	configFile, err := getConfigFile()
 	if err != nil {
-		panic(err)
+		return err
+	}
+
+	// Save passwords in secret store
+	config = config.Copy()
+	remotes := []*GitConfig{&config.Git}
+	for _, remote := range config.Remotes {
+		remotes = append(remotes, remote)
+	}
+	for _, remote := range remotes {
+		if len(remote.PrivateToken) != 0 {
+			if err := SavePassword(*remote); err != nil && !errors.Is(err, secret.ErrReadOnly) {
+				return err
+			}
+			// Cleanup password to avoid saving in FS
+			remote.PrivateToken = ""
+		}
 	}
 
-	file, _ := os.Create(configFile)
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(configFile)
+	if err != nil {
+		return err
+	}
 
 	defer closeFile(file)
 
-	writer := bufio.NewWriter(file)
+	return toml.NewEncoder(bufio.NewWriter(file)).Encode(config)
+}
+
+func getConfigFile() (string, error) {
 
-	// Save password on keychain
-	if len(config.Git.PrivateToken) != 0 {
-		SavePassword(config.Git)
-		// Cleanup password to avoid saving in FS
-		config.Git.PrivateToken = ""
+	workspaceDir, err := WorkspaceDir()
+	if err != nil {
+		return "", err
 	}
 
-	writeError := toml.NewEncoder(writer).Encode(config)
-	if writeError != nil {
-		panic(writeError)
+	return filepath.Join(workspaceDir, CONFIG_FILE), nil
+}
+
+func mustGetConfigFile() string {
+	configFile, _ := getConfigFile()
+	return configFile
+}
+
+// Return user config file, in XDG config folder ( default to ~/.config )
+func getUserConfigFile() string {
+
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		configDir = filepath.Join(homeDir, ".config")
 	}
+
+	return filepath.Join(configDir, USER_CONFIG_FILE)
 }
 
-func getConfigFile() (string, error) {
+func fileExist(path string) (bool, error) {
+
+	if path == "" {
+		return false, nil
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	} else {
+		return true, nil
+	}
+}
+
+// Return workspace root, the nearest parent folder containing config file ( current dir if none )
+func WorkspaceDir() (string, error) {
 
 	currentDir, err := os.Getwd()
 	if err != nil {
 		return "", errors.New("cannot retrieve current dir")
 	}
 
-	return filepath.Join(currentDir, CONFIG_FILE), nil
+	for dir := currentDir; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, CONFIG_FILE)); err == nil {
+			return dir, nil
+		}
+		if filepath.Dir(dir) == dir {
+			return currentDir, nil
+		}
+	}
 }
 
-func getServiceName(conf GitConfig) string {
-	gitUrl, err := url.Parse(conf.BaseUrl)
+// Return secret store selected for remote
+func SecretStore(conf GitConfig) (secret.Store, error) {
+
+	workspaceDir, err := WorkspaceDir()
 	if err != nil {
-		log.Fatalf("Cannot parse URL in GIT configuration : %s", err.Error())
-		os.Exit(1)
+		return nil, err
 	}
 
-	return KEYCHAIN_APP_PREFIX + strings.ToLower(gitUrl.Host)
+	return secret.New(secret.Options{
+		Backend: conf.SecretStore,
+		Env:     conf.SecretEnv,
+		File:    filepath.Join(workspaceDir, SECRETS_FILE),
+		Command: conf.SecretCommand,
+	})
 }
 
-func getPassword(conf GitConfig) string {
+// Return key of remote secret in secret store, token if name is empty ( ex: REFRESH_TOKEN_SECRET )
+func SecretKey(conf GitConfig, name string) (secret.Key, error) {
 
-	currentUser, _ := user.Current()
+	if _, err := url.Parse(conf.BaseUrl); err != nil {
+		return secret.Key{}, fmt.Errorf("cannot parse URL in GIT configuration ( %s )", err.Error())
+	}
 
-	secret, err := keyring.Get(getServiceName(conf), currentUser.Username)
+	currentUser, err := user.Current()
 	if err != nil {
-		log.Fatalf("Cannot retrieve password from keychain : %s", err.Error())
-		os.Exit(1)
+		return secret.Key{}, err
+	}
+
+	user := currentUser.Username
+	if name != "" {
+		user += "/" + name
 	}
 
-	return secret
+	return secret.Key{Url: conf.BaseUrl, User: user}, nil
 }
 
-func SavePassword(conf GitConfig) {
+func getPassword(conf GitConfig) (string, error) {
+	return GetSecret(conf, "")
+}
 
-	currentUser, _ := user.Current()
+// Save token of remote in its secret store, ErrReadOnly is returned if store cannot be written
+func SavePassword(conf GitConfig) error {
+	return SaveSecret(conf, "", conf.PrivateToken)
+}
+
+// Return secret of remote from its secret store, token if name is empty
+func GetSecret(conf GitConfig, name string) (string, error) {
 
-	err := keyring.Set(getServiceName(conf), currentUser.Username, conf.PrivateToken)
+	store, err := SecretStore(conf)
 	if err != nil {
-		log.Fatalf("Cannot store password on keychain : %s", err.Error())
-		os.Exit(1)
+		return "", err
+	}
+
+	key, err := SecretKey(conf, name)
+	if err != nil {
+		return "", err
+	}
+
+	return store.Get(key)
+}
+
+// Save secret of remote in its secret store, ErrReadOnly is returned if store cannot be written
+func SaveSecret(conf GitConfig, name string, value string) error {
+
+	store, err := SecretStore(conf)
+	if err != nil {
+		return err
+	}
+
+	key, err := SecretKey(conf, name)
+	if err != nil {
+		return err
+	}
+
+	if err := store.Set(key, value); err != nil {
+		return fmt.Errorf("cannot store token in secret store ( %w )", err)
 	}
 
+	return nil
 }
 
 func closeFile(f *os.File) {
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadMergeUserWorkspaceAndEnv(t *testing.T) {
+
+	userDir := t.TempDir()
+	workspace := t.TempDir()
+
+	t.Setenv("XDG_CONFIG_HOME", userDir)
+	writeFile(t, filepath.Join(userDir, USER_CONFIG_FILE), `
+[Git]
+Type = "gitlab"
+BaseUrl = "https://gitlab.example.com"
+CloneProtocol = "https"
+GroupIds = ["1"]
+
+[Initializr]
+Url = "https://start.example.com"
+`)
+	writeFile(t, filepath.Join(workspace, CONFIG_FILE), `
+[Git]
+GroupIds = ["2", "3"]
+NormalizeName = true
+`)
+
+	t.Setenv("MBX_GIT_CLONE_PROTOCOL", "ssh")
+	t.Setenv("MBX_GIT_PRIVATE_TOKEN", "secret")
+
+	// Config must be found from a sub folder of workspace
+	subFolder := filepath.Join(workspace, "service")
+	if err := os.Mkdir(subFolder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(subFolder)
+
+	conf, err := Load()
+	if err != nil {
+		t.Fatalf("Cannot load config : %s", err.Error())
+	}
+
+	expected := GitConfig{
+		Type:          "gitlab",
+		BaseUrl:       "https://gitlab.example.com",
+		AuthMode:      "pat",
+		PrivateToken:  "secret",
+		GroupIds:      []string{"2", "3"},
+		NormalizeName: true,
+		CloneProtocol: "ssh",
+	}
+
+	if !reflect.DeepEqual(conf.Git, expected) {
+		t.Errorf("Unexpected config\n got: %+v\nwant: %+v", conf.Git, expected)
+	}
+
+	if conf.Initializr.Url != "https://start.example.com" {
+		t.Errorf("Unexpected initializr URL '%s'", conf.Initializr.Url)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+
+	cases := map[string]string{
+		"Git.BaseUrl":                 "MBX_GIT_BASE_URL",
+		"Git.IncludeArchivedProjects": "MBX_GIT_INCLUDE_ARCHIVED_PROJECTS",
+		"Initializr.Url":              "MBX_INITIALIZR_URL",
+	}
+
+	for key, expected := range cases {
+		if name := EnvName(key); name != expected {
+			t.Errorf("EnvName(%s) = %s, want %s", key, name, expected)
+		}
+	}
+}
+
+func TestLoadRemotes(t *testing.T) {
+
+	workspace := t.TempDir()
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	writeFile(t, filepath.Join(workspace, CONFIG_FILE), `
+[Git]
+Type = "gitlab"
+BaseUrl = "https://gitlab.example.com"
+PrivateToken = "gitlab-secret"
+Layout = "namespace"
+
+[Remotes.oss]
+Type = "github"
+BaseUrl = "https://api.github.com"
+GroupIds = ["acme"]
+
+[Remotes.legacy]
+Type = "local"
+BaseUrl = "/srv/git"
+AuthMode = "none"
+`)
+
+	t.Setenv("MBX_REMOTES_OSS_PRIVATE_TOKEN", "github-secret")
+	t.Chdir(workspace)
+
+	conf, err := Load()
+	if err != nil {
+		t.Fatalf("Cannot load config : %s", err.Error())
+	}
+
+	if names := conf.RemoteNames(); !reflect.DeepEqual(names, []string{DEFAULT_REMOTE, "legacy", "oss"}) {
+		t.Errorf("Unexpected remote names %v", names)
+	}
+
+	oss := conf.ForRemote("oss")
+	if oss.Git.Type != "github" || oss.Git.PrivateToken != "github-secret" || oss.Git.CloneProtocol != "ssh" {
+		t.Errorf("Unexpected remote config %+v", oss.Git)
+	}
+	if oss.Git.Layout != "namespace" {
+		t.Errorf("Workspace layout must be kept on remote config, got '%s'", oss.Git.Layout)
+	}
+
+	if value, err := GetValue(*conf, "Remotes.legacy.BaseUrl"); err != nil || value != "/srv/git" {
+		t.Errorf("Unexpected value '%s' ( %v )", value, err)
+	}
+}
+
+func TestWorkspaceDir(t *testing.T) {
+
+	workspace, _ := filepath.EvalSymlinks(t.TempDir())
+	subFolder := filepath.Join(workspace, "team", "api")
+	if err := os.MkdirAll(subFolder, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Current dir is used when no config exists
+	t.Chdir(subFolder)
+	if dir, err := WorkspaceDir(); err != nil || dir != subFolder {
+		t.Errorf("Unexpected workspace dir '%s' without config", dir)
+	}
+
+	writeFile(t, filepath.Join(workspace, CONFIG_FILE), "")
+	if dir, err := WorkspaceDir(); err != nil || dir != workspace {
+		t.Errorf("Unexpected workspace dir '%s' from sub folder", dir)
+	}
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+const ENV_PREFIX = "MBX_"
+
+// Override config values with environment variables, named from section and field ( ex: MBX_GIT_BASE_URL )
+// Named remotes are overridden with remote name as section ( ex: MBX_REMOTES_OSS_BASE_URL )
+func applyEnv(conf *Config) error {
+
+	if err := walkFields(reflect.ValueOf(conf).Elem(), "", applyEnvField); err != nil {
+		return err
+	}
+
+	for name, remote := range conf.Remotes {
+		if err := walkFields(reflect.ValueOf(remote).Elem(), REMOTES_PREFIX+name+".", applyEnvField); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func applyEnvField(key string, field reflect.Value) error {
+
+	value, ok := os.LookupEnv(EnvName(key))
+	if !ok {
+		return nil
+	}
+
+	if err := setValue(field, value); err != nil {
+		return fmt.Errorf("invalid value for %s ( %s )", EnvName(key), err.Error())
+	}
+
+	return nil
+}
+
+// Return environment variable name of config key ( ex: Git.BaseUrl -> MBX_GIT_BASE_URL )
+func EnvName(key string) string {
+
+	var name strings.Builder
+	name.WriteString(ENV_PREFIX)
+
+	for _, part := range strings.Split(key, ".") {
+		if name.Len() > len(ENV_PREFIX) {
+			name.WriteRune('_')
+		}
+		for i, r := range part {
+			if i > 0 && unicode.IsUpper(r) && !unicode.IsUpper(rune(part[i-1])) {
+				name.WriteRune('_')
+			}
+			if r == '-' {
+				r = '_'
+			}
+			name.WriteRune(unicode.ToUpper(r))
+		}
+	}
+
+	return name.String()
+}
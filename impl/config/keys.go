@@ -0,0 +1,222 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+const REMOTES_PREFIX = "Remotes."
+
+// Return all config keys ( ex: Git.BaseUrl )
+func Keys() []string {
+
+	var keys []string
+	walkFields(reflect.ValueOf(&Config{}).Elem(), "", func(key string, field reflect.Value) error { //nolint:errcheck
+		keys = append(keys, key)
+		return nil
+	})
+
+	return keys
+}
+
+// Return config keys of named remotes ( ex: Remotes.oss.BaseUrl )
+func RemoteKeys(conf Config) []string {
+
+	var keys []string
+	for _, name := range conf.RemoteNames() {
+		if name == DEFAULT_REMOTE {
+			continue
+		}
+		walkFields(reflect.ValueOf(conf.Remotes[name]).Elem(), REMOTES_PREFIX+name+".", func(key string, field reflect.Value) error { //nolint:errcheck
+			keys = append(keys, key)
+			return nil
+		})
+	}
+
+	return keys
+}
+
+// Return value of config key as string, lists are joined with comma
+func GetValue(conf Config, key string) (string, error) {
+
+	field, err := findField(&conf, key)
+	if err != nil {
+		return "", err
+	}
+
+	switch field.Kind() {
+	case reflect.Slice:
+		return strings.Join(field.Interface().([]string), ","), nil
+	default:
+		return fmt.Sprint(field.Interface()), nil
+	}
+}
+
+// Set value of config key from string, lists are separated with comma
+func SetValue(conf *Config, key string, value string) error {
+
+	// Setting a key of an unknown remote declares it
+	if name, _, ok := splitRemoteKey(key); ok && conf.Remotes[name] == nil {
+		if conf.Remotes == nil {
+			conf.Remotes = map[string]*GitConfig{}
+		}
+		conf.Remotes[name] = &GitConfig{}
+	}
+
+	field, err := findField(conf, key)
+	if err != nil {
+		return err
+	}
+
+	return setValue(field, value)
+}
+
+// Reset config key to its default value
+func UnsetValue(conf *Config, key string) error {
+
+	field, err := findField(conf, key)
+	if err != nil {
+		return err
+	}
+
+	field.Set(reflect.Zero(field.Type()))
+	return nil
+}
+
+// Load workspace config file only, without user config, environment and defaults
+func LoadWorkspace() (*Config, error) {
+
+	var conf Config
+
+	configFile := mustGetConfigFile()
+	if exist, _ := fileExist(configFile); !exist {
+		return &conf, nil
+	}
+
+	if _, err := toml.DecodeFile(configFile, &conf); err != nil {
+		return nil, fmt.Errorf("cannot read '%s' ( %s )", configFile, err.Error())
+	}
+
+	return &conf, nil
+}
+
+// Return path of workspace config file
+func File() string {
+	return mustGetConfigFile()
+}
+
+// Return prefix of keys of remote ( ex: Git. for default remote, Remotes.oss. for named ones )
+func RemoteKeyPrefix(name string) string {
+	if name == DEFAULT_REMOTE {
+		return "Git."
+	}
+	return REMOTES_PREFIX + name + "."
+}
+
+// Return field matching with key ( case insensitive ), keys of named remotes are prefixed with Remotes.<name>.
+func findField(conf *Config, key string) (reflect.Value, error) {
+
+	root := reflect.ValueOf(conf).Elem()
+	prefix := ""
+
+	if name, _, ok := splitRemoteKey(key); ok {
+		remote, exist := conf.Remotes[name]
+		if !exist {
+			return reflect.Value{}, fmt.Errorf("unknown remote '%s'", name)
+		}
+		root = reflect.ValueOf(remote).Elem()
+		prefix = REMOTES_PREFIX + name + "."
+	}
+
+	var found reflect.Value
+	walkFields(root, prefix, func(fieldKey string, field reflect.Value) error { //nolint:errcheck
+		if strings.EqualFold(fieldKey, key) {
+			found = field
+		}
+		return nil
+	})
+
+	if !found.IsValid() {
+		return found, fmt.Errorf("unknown key '%s'", key)
+	}
+
+	return found, nil
+}
+
+// Split key of a named remote ( ex: Remotes.oss.BaseUrl ) into remote name and field
+func splitRemoteKey(key string) (string, string, bool) {
+
+	if !strings.HasPrefix(strings.ToLower(key), strings.ToLower(REMOTES_PREFIX)) {
+		return "", "", false
+	}
+
+	parts := strings.SplitN(key[len(REMOTES_PREFIX):], ".", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
+
+// Call fn for each leaf field of struct, with dotted key ( ex: Git.BaseUrl ), maps are skipped
+func walkFields(value reflect.Value, prefix string, fn func(key string, field reflect.Value) error) error {
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		key := prefix + value.Type().Field(i).Name
+
+		if field.Kind() == reflect.Map {
+			continue
+		}
+
+		if field.Kind() == reflect.Struct {
+			if err := walkFields(field, key+".", fn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := fn(key, field); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Set field from string value, lists are separated with comma
+func setValue(field reflect.Value, value string) error {
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Kind())
+	}
+
+	return nil
+}
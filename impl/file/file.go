@@ -3,6 +3,8 @@ package file
 import (
 	"os"
 	"path/filepath"
+
+	"github.com/vanroy/microcli/impl/config"
 )
 
 func Exist(path string) bool {
@@ -18,13 +20,14 @@ func Exist(path string) bool {
 	}
 }
 
+// Return absolute path of path relative to workspace root
 func Rel(path string) string {
 
-	currentDir, err := os.Getwd()
+	workspaceDir, err := config.WorkspaceDir()
 	if err != nil {
 		//MESSAGE
 		return ""
 	}
 
-	return filepath.Join(currentDir, path)
+	return filepath.Join(workspaceDir, path)
 }
@@ -0,0 +1,34 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vanroy/microcli/impl/config"
+)
+
+func TestRel(t *testing.T) {
+
+	workspace, _ := filepath.EvalSymlinks(t.TempDir())
+	subFolder := filepath.Join(workspace, "team")
+	if err := os.MkdirAll(filepath.Join(workspace, filepath.Dir(config.CONFIG_FILE)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workspace, config.CONFIG_FILE), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(subFolder, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Paths are relative to workspace root, even from a sub folder
+	t.Chdir(subFolder)
+
+	if path := Rel("team/api"); path != filepath.Join(workspace, "team", "api") {
+		t.Errorf("Unexpected path '%s'", path)
+	}
+	if !Exist("team") || Exist("api") {
+		t.Errorf("Unexpected existence from sub folder")
+	}
+}
@@ -0,0 +1,102 @@
+package git
+
+import (
+	"github.com/thoas/go-funk"
+	"github.com/vanroy/microcli/impl/action"
+	"github.com/vanroy/microcli/impl/config"
+	"github.com/vanroy/microcli/impl/output"
+	"github.com/vanroy/microcli/impl/prompt"
+)
+
+type actionRecord struct {
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	AppliesTo   string   `json:"appliesTo,omitempty" yaml:"appliesTo,omitempty"`
+	Params      []string `json:"params,omitempty" yaml:"params,omitempty"`
+}
+
+// List actions of workspace, with their description and parameters
+func listActions() error {
+
+	workspaceDir, _ := config.WorkspaceDir()
+	actions, err := action.List(action.Dir(workspaceDir))
+	if err != nil && len(actions) == 0 {
+		prompt.PrintErrorf("Cannot list actions ( %s )", err.Error())
+		return err
+	}
+
+	records := funk.Map(actions, func(act *action.Action) actionRecord {
+		return actionRecord{
+			Name:        act.Name,
+			Description: act.Description,
+			AppliesTo:   act.AppliesTo,
+			Params:      funk.Map(act.Params, func(param action.Param) string { return param.Name }).([]string),
+		}
+	}).([]actionRecord)
+
+	if output.IsStructured() {
+		return output.Print(records)
+	}
+
+	for _, act := range actions {
+		prompt.PrintItem(act.Name + " " + prompt.Color(prompt.FgBlue) + act.Description + prompt.Color(prompt.Reset))
+		for _, param := range act.Params {
+			prompt.PrintItem("  " + paramLabel(param))
+		}
+	}
+
+	if err != nil {
+		prompt.PrintErrorf("%s", err.Error())
+	}
+
+	return err
+}
+
+// Return script arguments of action, from command arguments then by prompt for missing parameters
+// Parameter values are set in values, to render templates of action
+func promptActionParams(act *action.Action, args []string, values map[string]string) ([]string, error) {
+
+	parsed, err := act.ParseArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, param := range act.Params {
+		value, ok := parsed[param.Name]
+		if !ok {
+			value = promptParam(param)
+		}
+		values[param.Name] = value
+	}
+
+	return act.Args(values)
+}
+
+// Prompt parameter value, empty value select default
+func promptParam(param action.Param) string {
+
+	if param.Type == action.CHOICE {
+		options := funk.Map(param.Choices, func(choice string) prompt.Option { return prompt.Option{Id: choice, Name: choice} }).([]prompt.Option)
+		prompt.PrintNewLine()
+		return prompt.Choice("Select "+paramLabel(param)+" :", options)
+	}
+
+	return prompt.Input("\nEnter " + paramLabel(param) + " :")
+}
+
+// Return parameter name, with its description, type and default value
+func paramLabel(param action.Param) string {
+
+	label := param.Name
+	if param.Description != "" {
+		label += " ( " + param.Description + " )"
+	}
+	if param.Type != action.STRING {
+		label += " [" + param.Type + "]"
+	}
+	if param.DefaultValue() != "" {
+		label += " default: " + param.DefaultValue()
+	}
+
+	return label
+}
@@ -3,6 +3,8 @@ package git
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 
@@ -106,10 +108,58 @@ type azCreatePullRequest struct {
 }
 
 type azPullRequest struct {
-	PullRequestId int          `json:"pullRequestId"`
-	Repository    azRepository `json:"repository"`
-	Url           string       `json:"url"`
-	Status        string       `json:"status"`
+	PullRequestId         int          `json:"pullRequestId"`
+	Repository            azRepository `json:"repository"`
+	Url                   string       `json:"url"`
+	Status                string       `json:"status"`
+	Title                 string       `json:"title"`
+	IsDraft               bool         `json:"isDraft"`
+	MergeStatus           string       `json:"mergeStatus"`
+	SourceRefName         string       `json:"sourceRefName"`
+	LastMergeSourceCommit azCommitRef  `json:"lastMergeSourceCommit"`
+	Reviewers             []struct {
+		Vote int `json:"vote"`
+	} `json:"reviewers"`
+}
+
+type azCommitRef struct {
+	CommitId string `json:"commitId"`
+}
+
+type azUpdatePullRequest struct {
+	Status                string               `json:"status"`
+	LastMergeSourceCommit *azCommitRef         `json:"lastMergeSourceCommit,omitempty"`
+	CompletionOptions     *azCompletionOptions `json:"completionOptions,omitempty"`
+}
+
+type azCompletionOptions struct {
+	MergeStrategy      string `json:"mergeStrategy"`
+	DeleteSourceBranch bool   `json:"deleteSourceBranch"`
+}
+
+type azRefUpdate struct {
+	Name        string `json:"name"`
+	OldObjectId string `json:"oldObjectId"`
+	NewObjectId string `json:"newObjectId"`
+}
+
+// Completion merge strategies by merge strategy
+var azMergeStrategies = map[string]string{
+	MERGE_STRATEGY_MERGE:  "noFastForward",
+	MERGE_STRATEGY_SQUASH: "squash",
+	MERGE_STRATEGY_REBASE: "rebase",
+}
+
+type azPolicyEvaluations struct {
+	Value []struct {
+		Status        string `json:"status"`
+		Configuration struct {
+			IsBlocking bool `json:"isBlocking"`
+			Type       struct {
+				DisplayName string `json:"displayName"`
+			} `json:"type"`
+		} `json:"configuration"`
+	} `json:"value"`
 }
 
 var azProjectVisibilities = []prompt.Option{
@@ -281,6 +331,133 @@ func (az *azure) createReviewRequest(repository *gitRepository, from string, int
 	return az.toReviewRequest(azReview.(*azPullRequest)), err
 }
 
+// Return pull request, with status of build policies and approvals of reviewers
+func (az *azure) getReviewRequest(repository *gitRepository, id string) (reviewRequest, error) {
+
+	request, err := az.getPullRequest(repository, id)
+	if err != nil {
+		return reviewRequest{}, err
+	}
+	review := az.toReviewRequest(request)
+
+	// Vote 10 is approved, 5 approved with suggestions
+	for _, reviewer := range request.Reviewers {
+		if reviewer.Vote >= 5 {
+			review.Approvals++
+		}
+	}
+
+	artifactId := "vstfs:///CodeReview/CodeReviewId/" + request.Repository.Project.Id + "/" + id
+	evaluations, err := az.execGet(repository.GroupId+"/_apis/policy/evaluations?artifactId="+url.QueryEscape(artifactId)+"&api-version=7.1-preview.1", &azPolicyEvaluations{})
+	if err != nil {
+		return reviewRequest{}, err
+	}
+
+	checks := []string{}
+	for _, evaluation := range evaluations.(*azPolicyEvaluations).Value {
+		if evaluation.Configuration.Type.DisplayName != "Build" {
+			continue
+		}
+		switch evaluation.Status {
+		case "approved", "notApplicable":
+			checks = append(checks, CHECKS_SUCCESS)
+		case "queued", "running":
+			checks = append(checks, CHECKS_PENDING)
+		default:
+			checks = append(checks, CHECKS_FAILURE)
+		}
+	}
+	review.Checks = combineChecks(checks...)
+
+	return review, nil
+}
+
+// Complete pull request with merge strategy, completion is rejected while policies are not fulfilled
+func (az *azure) mergeReviewRequest(repository *gitRepository, id string, strategy string, deleteBranch bool) error {
+
+	request, err := az.getPullRequest(repository, id)
+	if err != nil {
+		return err
+	}
+
+	data := azUpdatePullRequest{
+		Status:                "completed",
+		LastMergeSourceCommit: &request.LastMergeSourceCommit,
+		CompletionOptions:     &azCompletionOptions{MergeStrategy: azMergeStrategies[strategy], DeleteSourceBranch: deleteBranch},
+	}
+
+	_, err = az.execRequest(resty.MethodPatch, az.getPullRequestPath(repository, id), data, &azPullRequest{})
+	return err
+}
+
+// Abandon pull request, source branch is deleted by updating its ref to zero commit
+func (az *azure) closeReviewRequest(repository *gitRepository, id string, deleteBranch bool) error {
+
+	result, err := az.execRequest(resty.MethodPatch, az.getPullRequestPath(repository, id), azUpdatePullRequest{Status: "abandoned"}, &azPullRequest{})
+	if err != nil || !deleteBranch {
+		return err
+	}
+
+	request := result.(*azPullRequest)
+	refs := []azRefUpdate{{Name: request.SourceRefName, OldObjectId: request.LastMergeSourceCommit.CommitId, NewObjectId: strings.Repeat("0", 40)}}
+	_, err = az.execPost(repository.GroupId+"/_apis/git/repositories/"+repository.Id+"/refs?api-version=7.1", refs, &map[string]interface{}{})
+	return err
+}
+
+func (az *azure) updateReviewRequest(repository *gitRepository, id string) error {
+	return errors.New("updating source branch of pull request is not supported by Azure DevOps")
+}
+
+func (az *azure) getPullRequest(repository *gitRepository, id string) (*azPullRequest, error) {
+
+	result, err := az.execGet(az.getPullRequestPath(repository, id), &azPullRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	request := result.(*azPullRequest)
+	if request.PullRequestId == 0 {
+		return nil, fmt.Errorf("pull request %s not found", id)
+	}
+
+	return request, nil
+}
+
+func (az *azure) getPullRequestPath(repository *gitRepository, id string) string {
+	return repository.GroupId + "/_apis/git/repositories/" + repository.Id + "/pullrequests/" + id + "?api-version=7.1"
+}
+
+type azConnectionData struct {
+	AuthenticatedUser struct {
+		Id                  string `json:"id"`
+		ProviderDisplayName string `json:"providerDisplayName"`
+	} `json:"authenticatedUser"`
+}
+
+// Return identity of token, scopes of Azure DevOps tokens are not exposed
+func (az *azure) getTokenInfo() (tokenInfo, error) {
+
+	resp, err := az.authenticate(resty.New().R()).
+		SetResult(&azConnectionData{}).
+		Get(az.config.Git.BaseUrl + "/_apis/connectionData")
+
+	if err != nil {
+		return tokenInfo{}, err
+	}
+
+	// Rejected tokens are redirected to sign-in page
+	if resp.StatusCode() != http.StatusOK || !strings.Contains(resp.Header().Get("Content-Type"), "json") {
+		return tokenInfo{}, fmt.Errorf("cannot retrieve connection data. Status code : %d", resp.StatusCode())
+	}
+
+	user := resp.Result().(*azConnectionData).AuthenticatedUser
+	if user.ProviderDisplayName == "" || user.ProviderDisplayName == "Anonymous" {
+		return tokenInfo{}, errors.New("token is not authenticated")
+	}
+
+	return tokenInfo{User: user.ProviderDisplayName}, nil
+}
+
 func (az *azure) execGet(url string, resultType interface{}) (interface{}, error) {
 
 	resp, err := az.authenticate(resty.New().R()).
@@ -295,18 +472,22 @@ func (az *azure) execGet(url string, resultType interface{}) (interface{}, error
 }
 
 func (az *azure) execPost(url string, data interface{}, resultType interface{}) (interface{}, error) {
+	return az.execRequest(resty.MethodPost, url, data, resultType)
+}
+
+func (az *azure) execRequest(method string, url string, data interface{}, resultType interface{}) (interface{}, error) {
 
 	resp, err := az.authenticate(resty.New().R()).
 		SetResult(resultType).
 		SetBody(data).
-		Post(az.config.Git.BaseUrl + "/" + url)
+		Execute(method, az.config.Git.BaseUrl+"/"+url)
 
 	if err != nil {
 		return nil, err
 	}
 
 	if resp.StatusCode() < 200 || resp.StatusCode() > 300 {
-		return nil, fmt.Errorf("cannot execute post request. Status code : %d. Message : %s", resp.StatusCode(), resp.String())
+		return nil, fmt.Errorf("cannot execute %s request. Status code : %d. Message : %s", strings.ToLower(method), resp.StatusCode(), resp.String())
 	}
 
 	return resp.Result(), nil
@@ -361,11 +542,28 @@ func (az *azure) toGitGroup(project azProject) gitGroup {
 }
 func (az *azure) toReviewRequest(request *azPullRequest) reviewRequest {
 
+	state := REVIEW_OPEN
+	if request.Status == "completed" {
+		state = REVIEW_MERGED
+	} else if request.Status == "abandoned" {
+		state = REVIEW_CLOSED
+	} else if request.IsDraft {
+		state = REVIEW_DRAFT
+	}
+
+	mergeable := MERGEABLE_UNKNOWN
+	if request.MergeStatus == "succeeded" {
+		mergeable = MERGEABLE_YES
+	} else if request.MergeStatus == "conflicts" || request.MergeStatus == "failure" || request.MergeStatus == "rejectedByPolicy" {
+		mergeable = MERGEABLE_CONFLICT
+	}
+
 	return reviewRequest{
 		Id:        strconv.Itoa(request.PullRequestId),
+		Title:     request.Title,
 		Url:       fmt.Sprintf("%s/pullrequest/%d", request.Repository.WebUrl, request.PullRequestId),
-		State:     request.Status,
-		Mergeable: "",
+		State:     state,
+		Mergeable: mergeable,
 	}
 }
 
@@ -0,0 +1,68 @@
+package git
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vanroy/microcli/impl/config"
+)
+
+func TestAzureMergeReviewRequest(t *testing.T) {
+
+	var updates []azUpdatePullRequest
+	var refUpdates []azRefUpdate
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/acme/_apis/git/repositories/r1/pullrequests/12", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPatch {
+			var update azUpdatePullRequest
+			json.NewDecoder(r.Body).Decode(&update) //nolint:errcheck
+			updates = append(updates, update)
+		}
+		w.Write([]byte(`{"pullRequestId": 12, "status": "active", "sourceRefName": "refs/heads/mbx/bump", "lastMergeSourceCommit": {"commitId": "abc"}}`)) //nolint:errcheck
+	})
+	mux.HandleFunc("POST /acme/_apis/git/repositories/r1/refs", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&refUpdates) //nolint:errcheck
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`)) //nolint:errcheck
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	azure := newAzure(config.Config{Git: config.GitConfig{Type: "azure", BaseUrl: server.URL, PrivateToken: "secret"}})
+	repo := &gitRepository{Id: "r1", GroupId: "acme"}
+
+	if err := azure.mergeReviewRequest(repo, "12", MERGE_STRATEGY_SQUASH, true); err != nil {
+		t.Fatalf("Cannot merge : %s", err.Error())
+	}
+	if len(updates) != 1 {
+		t.Fatalf("Expected one completion request, got %d", len(updates))
+	}
+	// Completion must reference last merge source commit, to not merge commits pushed after review
+	update := updates[0]
+	if update.Status != "completed" || update.LastMergeSourceCommit == nil || update.LastMergeSourceCommit.CommitId != "abc" {
+		t.Errorf("Unexpected completion %+v", update)
+	}
+	if update.CompletionOptions == nil || update.CompletionOptions.MergeStrategy != "squash" || !update.CompletionOptions.DeleteSourceBranch {
+		t.Errorf("Unexpected completion options %+v", update.CompletionOptions)
+	}
+
+	if err := azure.closeReviewRequest(repo, "12", true); err != nil {
+		t.Fatalf("Cannot close : %s", err.Error())
+	}
+	if len(updates) != 2 || updates[1].Status != "abandoned" || updates[1].CompletionOptions != nil {
+		t.Errorf("Unexpected abandon %+v", updates[1:])
+	}
+	if fmt.Sprintf("%+v", refUpdates) != fmt.Sprintf("%+v", []azRefUpdate{{Name: "refs/heads/mbx/bump", OldObjectId: "abc", NewObjectId: "0000000000000000000000000000000000000000"}}) {
+		t.Errorf("Unexpected ref updates %+v", refUpdates)
+	}
+
+	if err := azure.updateReviewRequest(repo, "12"); err == nil {
+		t.Errorf("Expected update of source branch to be unsupported")
+	}
+}
@@ -0,0 +1,614 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/thoas/go-funk"
+	"github.com/urfave/cli/v3"
+	"github.com/vanroy/microcli/impl/config"
+	"github.com/vanroy/microcli/impl/prompt"
+)
+
+const (
+	BB_PAGE_LIMIT = 100
+)
+
+type bitbucket struct {
+	config   config.Config
+	labels   Labels
+	apiUrl   string
+	userSlug string
+}
+
+type bbPage struct {
+	Size          int  `json:"size"`
+	Limit         int  `json:"limit"`
+	Start         int  `json:"start"`
+	IsLastPage    bool `json:"isLastPage"`
+	NextPageStart int  `json:"nextPageStart"`
+}
+
+type bbProject struct {
+	Id          int    `json:"id"`
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Public      bool   `json:"public"`
+	Type        string `json:"type"`
+}
+
+type bbProjectPage struct {
+	bbPage
+	Values []bbProject `json:"values"`
+}
+
+type bbLink struct {
+	Href string `json:"href"`
+	Name string `json:"name"`
+}
+
+type bbLinks struct {
+	Clone []bbLink `json:"clone"`
+	Self  []bbLink `json:"self"`
+}
+
+type bbRepository struct {
+	Id          int       `json:"id"`
+	Slug        string    `json:"slug"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Archived    bool      `json:"archived"`
+	Project     bbProject `json:"project"`
+	Links       bbLinks   `json:"links"`
+}
+
+type bbRepositoryPage struct {
+	bbPage
+	Values []bbRepository `json:"values"`
+}
+
+type bbBranch struct {
+	Id        string `json:"id"`
+	DisplayId string `json:"displayId"`
+}
+
+type bbCreateProject struct {
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type bbCreateRepository struct {
+	Name  string `json:"name"`
+	ScmId string `json:"scmId"`
+}
+
+type bbRef struct {
+	Id string `json:"id"`
+}
+
+type bbCreatePullRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	FromRef     bbRef  `json:"fromRef"`
+	ToRef       bbRef  `json:"toRef"`
+	Draft       bool   `json:"draft"`
+}
+
+type bbMergeResult struct {
+	Outcome string `json:"outcome"`
+}
+
+type bbPullRequestProperties struct {
+	MergeResult bbMergeResult `json:"mergeResult"`
+}
+
+type bbPullRequest struct {
+	Id         int                     `json:"id"`
+	Title      string                  `json:"title"`
+	State      string                  `json:"state"`
+	Draft      bool                    `json:"draft"`
+	Links      bbLinks                 `json:"links"`
+	Properties bbPullRequestProperties `json:"properties"`
+	Version    int                     `json:"version"`
+	FromRef    struct {
+		Id           string `json:"id"`
+		LatestCommit string `json:"latestCommit"`
+	} `json:"fromRef"`
+	Reviewers []struct {
+		Approved bool `json:"approved"`
+	} `json:"reviewers"`
+}
+
+// Merge strategy ids by merge strategy
+var bbMergeStrategies = map[string]string{
+	MERGE_STRATEGY_MERGE:  "no-ff",
+	MERGE_STRATEGY_SQUASH: "squash",
+	MERGE_STRATEGY_REBASE: "rebase-no-ff",
+}
+
+type bbBuildStatusPage struct {
+	bbPage
+	Values []struct {
+		State string `json:"state"`
+	} `json:"values"`
+}
+
+func newBitbucket(config config.Config) gitRemote {
+
+	baseUrl := config.Git.BaseUrl
+
+	if !strings.HasSuffix(baseUrl, "/") {
+		baseUrl += "/"
+	}
+	baseUrl += "rest/api/1.0"
+
+	return &bitbucket{
+		config: config,
+		labels: Labels{
+			GroupLabel:            "project",
+			GroupsLabel:           "projects",
+			RepositoryLabel:       "repository",
+			RepositoriesLabel:     "repositories",
+			CreateGroupUsage:      "key name [description]",
+			CreateRepositoryUsage: "[project] name",
+			CodeReviewRequest:     "pull request",
+		},
+		apiUrl: baseUrl,
+	}
+}
+
+func (bb *bitbucket) getLabels() Labels {
+	return bb.labels
+}
+
+func (bb *bitbucket) createGroup(args cli.Args) (string, error) {
+
+	projectKey := prompt.Input("Enter your project key :", args.Get(0))
+	projectName := prompt.Input("\nEnter your project name :", args.Get(1))
+	projectDescription := prompt.Input("\nEnter your project description :", args.Get(2))
+	prompt.PrintNewLine()
+
+	if projectKey == "" || projectName == "" {
+		prompt.PrintErrorf("Missing parameters, project key and project name are required")
+		return "", errors.New("missing parameters")
+	}
+
+	data := bbCreateProject{
+		Key:         strings.ToUpper(projectKey),
+		Name:        projectName,
+		Description: projectDescription,
+	}
+
+	createResp, err := bb.execPost("projects", data, &bbProject{})
+	if err != nil {
+		prompt.PrintErrorf("Cannot create project. ( %s )", err.Error())
+		return "", err
+	}
+
+	prompt.PrintInfo("Project created")
+
+	return createResp.(*bbProject).Key, nil
+}
+
+func (bb *bitbucket) createRepository(args cli.Args) (string, error) {
+
+	groups, _ := bb.getGroups()
+
+	groups = funk.Filter(groups, func(g gitGroup) bool { return funk.ContainsString(bb.config.Git.GroupIds, g.Id) }).([]gitGroup)
+
+	idx := 0
+	groupId := ""
+	if len(groups) == 0 {
+		prompt.PrintErrorf("No projects available")
+		return "", errors.New("no projects available")
+	} else if len(groups) == 1 {
+		groupId = groups[0].Id
+	} else {
+		defaultGroupId := ""
+		groupOptions := funk.Map(groups, func(group gitGroup) prompt.Option {
+			if args.Get(0) == group.Name || args.Get(0) == group.Id {
+				defaultGroupId = group.Id
+			}
+			return prompt.Option{Id: group.Id, Name: group.Name}
+		}).([]prompt.Option)
+
+		groupId = prompt.Choice("Select your project :", groupOptions, defaultGroupId)
+		idx = idx + 1
+	}
+
+	repositoryName := prompt.Input("\nEnter your repository name :", args.Get(idx))
+	prompt.PrintNewLine()
+
+	if groupId == "" || repositoryName == "" {
+		prompt.PrintErrorf("Missing parameters, repository name and project are required")
+		return "", errors.New("missing parameters")
+	}
+
+	data := bbCreateRepository{
+		Name:  repositoryName,
+		ScmId: "git",
+	}
+
+	createResp, err := bb.execPost(bb.getGroupBasePath(groupId)+"/repos", data, &bbRepository{})
+	if err != nil {
+		prompt.PrintErrorf("Cannot create repository. ( %s )", err.Error())
+		return "", err
+	}
+
+	prompt.PrintInfo("Repository created")
+
+	return strconv.Itoa(createResp.(*bbRepository).Id), nil
+}
+
+func (bb *bitbucket) getGroups() ([]gitGroup, error) {
+
+	var groups []gitGroup
+
+	start := 0
+	for {
+		resp, err := bb.execGet("projects?start="+strconv.Itoa(start)+"&limit="+strconv.Itoa(BB_PAGE_LIMIT), &bbProjectPage{})
+		if err != nil {
+			return nil, err
+		}
+
+		page := resp.(*bbProjectPage)
+		groups = append(groups, funk.Map(page.Values, bb.toGitGroup).([]gitGroup)...)
+
+		if page.IsLastPage {
+			break
+		}
+		start = page.NextPageStart
+	}
+
+	// Add default personal group to allow user to read personal repositories
+	groups = append(groups, PERSONAL_GROUP)
+
+	return groups, nil
+}
+
+func (bb *bitbucket) getRepositories() ([]gitRepository, error) {
+
+	var repos []gitRepository
+
+	funk.ForEach(bb.config.Git.GroupIds, func(groupId string) {
+
+		start := 0
+		for {
+			resp, err := bb.execGet(bb.getGroupBasePath(groupId)+"/repos?start="+strconv.Itoa(start)+"&limit="+strconv.Itoa(BB_PAGE_LIMIT), &bbRepositoryPage{})
+			if err != nil {
+				break
+			}
+
+			page := resp.(*bbRepositoryPage)
+			filteredRepos := page.Values
+			if !bb.config.Git.IncludeArchivedProjects {
+				filteredRepos = funk.Filter(filteredRepos, func(repo bbRepository) bool { return !repo.Archived }).([]bbRepository)
+			}
+			repos = append(repos, funk.Map(filteredRepos, bb.toGitRepo(groupId)).([]gitRepository)...)
+
+			if page.IsLastPage {
+				break
+			}
+			start = page.NextPageStart
+		}
+	})
+
+	return repos, nil
+}
+
+func (bb *bitbucket) createReviewRequest(repository *gitRepository, from string, into string, title string, message string, draft bool) (reviewRequest, error) {
+
+	data := bbCreatePullRequest{
+		Title:       title,
+		Description: message,
+		FromRef:     bbRef{Id: "refs/heads/" + strings.TrimPrefix(from, "refs/heads/")},
+		ToRef:       bbRef{Id: "refs/heads/" + strings.TrimPrefix(into, "refs/heads/")},
+		Draft:       draft,
+	}
+
+	bbReview, err := bb.execPost(bb.getRepositoryBasePath(repository)+"/pull-requests", data, &bbPullRequest{})
+	if err != nil {
+		return reviewRequest{}, err
+	}
+
+	return bb.toReviewRequest(bbReview.(*bbPullRequest)), nil
+}
+
+// Return pull request, with build statuses of its last commit and approvals of reviewers
+func (bb *bitbucket) getReviewRequest(repository *gitRepository, id string) (reviewRequest, error) {
+
+	request, err := bb.getPullRequest(repository, id)
+	if err != nil {
+		return reviewRequest{}, err
+	}
+	review := bb.toReviewRequest(request)
+
+	for _, reviewer := range request.Reviewers {
+		if reviewer.Approved {
+			review.Approvals++
+		}
+	}
+
+	statuses, err := bb.execGet(bb.getRestUrl("build-status")+"/commits/"+request.FromRef.LatestCommit, &bbBuildStatusPage{})
+	if err != nil {
+		return reviewRequest{}, err
+	}
+
+	checks := []string{}
+	for _, status := range statuses.(*bbBuildStatusPage).Values {
+		switch status.State {
+		case "SUCCESSFUL":
+			checks = append(checks, CHECKS_SUCCESS)
+		case "INPROGRESS":
+			checks = append(checks, CHECKS_PENDING)
+		default:
+			checks = append(checks, CHECKS_FAILURE)
+		}
+	}
+	review.Checks = combineChecks(checks...)
+
+	return review, nil
+}
+
+// Merge pull request at its current version, source branch is deleted with branch utils API
+func (bb *bitbucket) mergeReviewRequest(repository *gitRepository, id string, strategy string, deleteBranch bool) error {
+
+	request, err := bb.getPullRequest(repository, id)
+	if err != nil {
+		return err
+	}
+
+	data := map[string]string{"strategyId": bbMergeStrategies[strategy]}
+	if _, err := bb.execPost(bb.getRepositoryBasePath(repository)+"/pull-requests/"+id+"/merge?version="+strconv.Itoa(request.Version), data, &bbPullRequest{}); err != nil {
+		return err
+	}
+
+	if deleteBranch {
+		return bb.deleteBranch(repository, request.FromRef.Id)
+	}
+	return nil
+}
+
+// Decline pull request at its current version
+func (bb *bitbucket) closeReviewRequest(repository *gitRepository, id string, deleteBranch bool) error {
+
+	request, err := bb.getPullRequest(repository, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := bb.execPost(bb.getRepositoryBasePath(repository)+"/pull-requests/"+id+"/decline?version="+strconv.Itoa(request.Version), map[string]string{}, &bbPullRequest{}); err != nil {
+		return err
+	}
+
+	if deleteBranch {
+		return bb.deleteBranch(repository, request.FromRef.Id)
+	}
+	return nil
+}
+
+// Rebase source branch of pull request on target branch with GIT API
+func (bb *bitbucket) updateReviewRequest(repository *gitRepository, id string) error {
+
+	request, err := bb.getPullRequest(repository, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = bb.execPost(bb.getRestUrl("git")+"/"+bb.getRepositoryBasePath(repository)+"/pull-requests/"+id+"/rebase", map[string]int{"version": request.Version}, &map[string]interface{}{})
+	return err
+}
+
+func (bb *bitbucket) getPullRequest(repository *gitRepository, id string) (*bbPullRequest, error) {
+
+	result, err := bb.execGet(bb.getRepositoryBasePath(repository)+"/pull-requests/"+id, &bbPullRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.(*bbPullRequest), nil
+}
+
+func (bb *bitbucket) deleteBranch(repository *gitRepository, ref string) error {
+	_, err := bb.execRequest(resty.MethodDelete, bb.getRestUrl("branch-utils")+"/"+bb.getRepositoryBasePath(repository)+"/branches", map[string]interface{}{"name": ref, "dryRun": false}, nil)
+	return err
+}
+
+// Return URL of other REST API than core one ( ex: build-status ), served next to it
+func (bb *bitbucket) getRestUrl(api string) string {
+	return strings.TrimSuffix(bb.apiUrl, "api/1.0") + api + "/1.0"
+}
+
+// Return identity of token, permissions of Bitbucket tokens are not exposed
+func (bb *bitbucket) getTokenInfo() (tokenInfo, error) {
+
+	bb.userSlug = ""
+	if _, err := bb.execGet("application-properties", &map[string]interface{}{}); err != nil {
+		return tokenInfo{}, err
+	}
+
+	// Anonymous requests are allowed without user header
+	if bb.userSlug == "" {
+		return tokenInfo{}, errors.New("token is not authenticated")
+	}
+
+	return tokenInfo{User: bb.userSlug}, nil
+}
+
+func (bb *bitbucket) execGet(url string, resultType interface{}) (interface{}, error) {
+
+	// Other REST APIs than core one are requested with absolute URLs
+	requestUrl := url
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		requestUrl = bb.apiUrl + "/" + url
+	}
+
+	resp, err := resty.New().R().
+		SetHeader("Authorization", "Bearer "+bb.config.Git.PrivateToken).
+		SetHeader("Accept", "application/json").
+		SetResult(resultType).
+		Get(requestUrl)
+
+	if err != nil {
+		prompt.PrintError(resp.String())
+		return nil, err
+	}
+
+	if resp.StatusCode() < 200 || resp.StatusCode() > 300 {
+		return nil, fmt.Errorf("cannot execute get request. Status code : %d. Message : %s", resp.StatusCode(), resp.String())
+	}
+
+	if bb.userSlug == "" {
+		bb.userSlug = resp.Header().Get("X-AUSERNAME")
+	}
+
+	return resp.Result(), nil
+}
+
+func (bb *bitbucket) execPost(url string, data interface{}, resultType interface{}) (interface{}, error) {
+	return bb.execRequest(resty.MethodPost, url, data, resultType)
+}
+
+func (bb *bitbucket) execRequest(method string, url string, data interface{}, resultType interface{}) (interface{}, error) {
+
+	// Other REST APIs than core one are requested with absolute URLs
+	requestUrl := url
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		requestUrl = bb.apiUrl + "/" + url
+	}
+
+	resp, err := resty.New().R().
+		SetHeader("Authorization", "Bearer "+bb.config.Git.PrivateToken).
+		SetHeader("Accept", "application/json").
+		SetResult(resultType).
+		SetBody(data).
+		Execute(method, requestUrl)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode() < 200 || resp.StatusCode() > 300 {
+		return nil, fmt.Errorf("cannot execute %s request. Status code : %d. Message : %s", strings.ToLower(method), resp.StatusCode(), resp.String())
+	}
+
+	return resp.Result(), nil
+}
+
+func (bb *bitbucket) toGitGroup(project bbProject) gitGroup {
+
+	return gitGroup{
+		Id:   project.Key,
+		Name: project.Name,
+	}
+}
+
+func (bb *bitbucket) toGitRepo(groupId string) interface{} {
+
+	return func(repository bbRepository) gitRepository {
+
+		var sshUrl, httpUrl string
+		for _, link := range repository.Links.Clone {
+			switch link.Name {
+			case "ssh":
+				sshUrl = link.Href
+			case "http", "https":
+				httpUrl = link.Href
+			}
+		}
+
+		return gitRepository{
+			Id:                strconv.Itoa(repository.Id),
+			Name:              repository.Name,
+			Description:       repository.Description,
+			NameWithNamespace: repository.Project.Name + " / " + repository.Name,
+			Path:              bb.normalize(repository.Slug),
+			PathWithNamespace: bb.normalize(repository.Project.Key + "/" + repository.Slug),
+			SshUrl:            sshUrl,
+			HttpUrl:           httpUrl,
+			Archived:          repository.Archived,
+			GroupId:           groupId,
+			ProjectKey:        repository.Project.Key,
+			Slug:              repository.Slug,
+		}
+	}
+}
+
+func (bb *bitbucket) toReviewRequest(request *bbPullRequest) reviewRequest {
+
+	var webUrl string
+	if len(request.Links.Self) > 0 {
+		webUrl = request.Links.Self[0].Href
+	}
+
+	state := REVIEW_OPEN
+	if request.State == "MERGED" {
+		state = REVIEW_MERGED
+	} else if request.State == "DECLINED" {
+		state = REVIEW_CLOSED
+	} else if request.Draft {
+		state = REVIEW_DRAFT
+	}
+
+	mergeable := MERGEABLE_UNKNOWN
+	if request.Properties.MergeResult.Outcome == "CLEAN" {
+		mergeable = MERGEABLE_YES
+	} else if request.Properties.MergeResult.Outcome == "CONFLICTED" {
+		mergeable = MERGEABLE_CONFLICT
+	}
+
+	return reviewRequest{
+		Id:        strconv.Itoa(request.Id),
+		Title:     request.Title,
+		Url:       webUrl,
+		State:     state,
+		Mergeable: mergeable,
+	}
+}
+
+// Return default branch, resolved on demand as the repository listing does not include it
+func (bb *bitbucket) getDefaultBranch(repository *gitRepository) string {
+
+	repositoryPath := bb.getRepositoryBasePath(repository)
+
+	resp, err := bb.execGet(repositoryPath+"/default-branch", &bbBranch{})
+	if err != nil {
+		// Fallback on legacy endpoint ( Bitbucket Server < 7.5 )
+		resp, err = bb.execGet(repositoryPath+"/branches/default", &bbBranch{})
+		if err != nil {
+			return ""
+		}
+	}
+
+	return resp.(*bbBranch).DisplayId
+}
+
+// Return API path of repository, from project key and slug returned by Bitbucket when listing repositories
+func (bb *bitbucket) getRepositoryBasePath(repository *gitRepository) string {
+	return "projects/" + url.PathEscape(repository.ProjectKey) + "/repos/" + url.PathEscape(repository.Slug)
+}
+
+func (bb *bitbucket) getGroupBasePath(groupId string) string {
+	if groupId == PERSONAL_GROUP.Id {
+		if bb.userSlug == "" {
+			// Any authenticated request return the current user slug
+			bb.execGet("application-properties", &map[string]interface{}{}) //nolint:errcheck
+		}
+		return "projects/~" + bb.userSlug
+	} else {
+		return "projects/" + groupId
+	}
+}
+
+func (bb *bitbucket) normalize(name string) string {
+	if !bb.config.Git.NormalizeName {
+		return name
+	}
+	return strings.ToLower(strings.ReplaceAll(name, " ", "-"))
+}
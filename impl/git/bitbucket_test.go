@@ -0,0 +1,80 @@
+package git
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vanroy/microcli/impl/config"
+)
+
+// Fake Bitbucket Server API serving two pages of repositories for project "PLAT"
+func newFakeBitbucket(t *testing.T, defaultBranchRequests *int) *httptest.Server {
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/rest/api/1.0/projects/PLAT/repos", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		page := bbRepositoryPage{bbPage: bbPage{NextPageStart: 1}, Values: []bbRepository{{Id: 1, Slug: "api", Name: "API", Project: bbProject{Key: "PLAT", Name: "Platform"}}}}
+		if r.URL.Query().Get("start") == "1" {
+			page = bbRepositoryPage{bbPage: bbPage{IsLastPage: true}, Values: []bbRepository{{Id: 2, Slug: "web", Name: "Web", Project: bbProject{Key: "PLAT", Name: "Platform"}}}}
+		}
+		json.NewEncoder(w).Encode(page) //nolint:errcheck
+	})
+
+	mux.HandleFunc("/rest/api/1.0/projects/PLAT/repos/api/default-branch", func(w http.ResponseWriter, r *http.Request) {
+		*defaultBranchRequests++
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(bbBranch{Id: "refs/heads/main", DisplayId: "main"}) //nolint:errcheck
+	})
+
+	mux.HandleFunc("/rest/api/1.0/projects/PLAT/repos/api/pull-requests", func(w http.ResponseWriter, r *http.Request) {
+		var data bbCreatePullRequest
+		json.NewDecoder(r.Body).Decode(&data) //nolint:errcheck
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(bbPullRequest{Id: 7, Title: data.Title, State: "OPEN", Links: bbLinks{Self: []bbLink{{Href: "http://bitbucket/projects/PLAT/repos/api/pull-requests/7"}}}}) //nolint:errcheck
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestBitbucketRepositories(t *testing.T) {
+
+	defaultBranchRequests := 0
+	server := newFakeBitbucket(t, &defaultBranchRequests)
+	t.Cleanup(server.Close)
+
+	bb := newBitbucket(config.Config{Git: config.GitConfig{BaseUrl: server.URL, PrivateToken: "secret", GroupIds: []string{"PLAT"}}})
+
+	repos, err := bb.getRepositories()
+	if err != nil {
+		t.Fatalf("Cannot retrieve repositories : %s", err.Error())
+	}
+
+	if len(repos) != 2 || repos[0].PathWithNamespace != "PLAT/api" || repos[1].Slug != "web" {
+		t.Fatalf("Unexpected repositories %+v", repos)
+	}
+	if defaultBranchRequests != 0 {
+		t.Errorf("default branch should not be requested while listing repositories")
+	}
+
+	if branch := bb.(defaultBranchResolver).getDefaultBranch(&repos[0]); branch != "main" || defaultBranchRequests != 1 {
+		t.Errorf("Unexpected default branch '%s'", branch)
+	}
+
+	review, err := bb.createReviewRequest(&repos[0], "feature", "main", "Update deps", "", false)
+	if err != nil {
+		t.Fatalf("Cannot create pull request : %s", err.Error())
+	}
+	if review.Id != "7" || review.State != REVIEW_OPEN || review.Url != "http://bitbucket/projects/PLAT/repos/api/pull-requests/7" {
+		t.Errorf("Unexpected review request %+v", review)
+	}
+}
@@ -0,0 +1,477 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/thoas/go-funk"
+	"github.com/urfave/cli/v3"
+	"github.com/vanroy/microcli/impl/config"
+	"github.com/vanroy/microcli/impl/output"
+	"github.com/vanroy/microcli/impl/prompt"
+)
+
+const (
+	CAMPAIGNS_DIR = ".microbox/campaigns"
+
+	STEP_PENDING     = "pending"
+	STEP_INITIALIZED = "initialized"
+	STEP_EXECUTED    = "executed"
+	STEP_COMMITTED   = "committed"
+	STEP_PUSHED      = "pushed"
+	STEP_REVIEWED    = "review-created"
+
+	// Final steps, repository is not executed again on resume
+	STEP_DONE      = "done"
+	STEP_UNCHANGED = "unchanged"
+)
+
+// Ordered steps of execution, a repository is resumed after its last reached step
+var campaignSteps = []string{STEP_PENDING, STEP_INITIALIZED, STEP_EXECUTED, STEP_COMMITTED, STEP_PUSHED}
+
+// Execution of action on repositories, recorded after each step to be resumed
+type campaign struct {
+	Id            string          `json:"id"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	Glob          string          `json:"glob"`
+	Exclude       []string        `json:"exclude,omitempty"`
+	Action        string          `json:"action"`
+	Params        []string        `json:"params,omitempty"`
+	Branch        string          `json:"branch,omitempty"`
+	CommitMessage string          `json:"commitMessage,omitempty"`
+	Review        bool            `json:"review"`
+	ReviewTitle   string          `json:"reviewTitle,omitempty"`
+	ReviewMessage string          `json:"reviewMessage,omitempty"`
+	ReviewDraft   bool            `json:"reviewDraft"`
+	Repositories  []*campaignRepo `json:"repositories"`
+}
+
+// Step of repository in campaign, with review request once created
+type campaignRepo struct {
+	Name      string    `json:"name"`
+	Step      string    `json:"step"`
+	ReviewId  string    `json:"reviewId,omitempty"`
+	ReviewUrl string    `json:"reviewUrl,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Returned when user quits campaign at a prompt, campaign can be resumed
+var errCampaignQuit = errors.New("campaign interrupted")
+
+// Campaign being executed, printed with command to resume it when execution is interrupted
+var runningCampaign atomic.Pointer[campaign]
+
+// Return campaigns directory of workspace
+func campaignsDir() string {
+	workspaceDir, _ := config.WorkspaceDir()
+	return filepath.Join(workspaceDir, CAMPAIGNS_DIR)
+}
+
+// Return new campaign for action, identified by its start time and action name
+func newCampaign(actionName string) *campaign {
+
+	now := time.Now()
+	return &campaign{
+		Id:        now.Format("20060102-150405") + "-" + strings.ReplaceAll(actionName, string(filepath.Separator), "-"),
+		CreatedAt: now,
+		Action:    actionName,
+	}
+}
+
+// Load campaign of workspace by id
+func loadCampaign(id string) (*campaign, error) {
+
+	content, err := os.ReadFile(filepath.Join(campaignsDir(), id+".json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("campaign '%s' not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	c := &campaign{}
+	if err := json.Unmarshal(content, c); err != nil {
+		return nil, fmt.Errorf("invalid campaign '%s' ( %s )", id, err.Error())
+	}
+
+	return c, nil
+}
+
+// Write campaign, through a temporary file to never leave a truncated campaign
+func (c *campaign) save() error {
+
+	dir := campaignsDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, c.Id+".json")
+	if err := os.WriteFile(path+".tmp", content, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+// Set step of repository and save campaign, error of previous attempt is cleared
+func (c *campaign) setStep(repo *campaignRepo, step string) error {
+	repo.Step = step
+	repo.Error = ""
+	repo.UpdatedAt = time.Now()
+	return c.save()
+}
+
+// Set error of repository and save campaign, step is kept to resume from it
+func (c *campaign) setError(repo *campaignRepo, err string) error {
+	repo.Error = err
+	repo.UpdatedAt = time.Now()
+	return c.save()
+}
+
+// Return true if a review request is created for repository, it requires a dedicated branch
+func (c *campaign) reviewable(repo gitRepository, defaultBranch string) bool {
+	return repo.Id != "" && len(c.Branch) > 0 && c.Branch != defaultBranch && c.Review && len(c.ReviewTitle) > 0
+}
+
+// Print remaining repositories of campaign, with command to resume it
+func printCampaignResult(c *campaign) error {
+
+	remaining := funk.Filter(c.Repositories, func(repo *campaignRepo) bool { return !repo.finished() }).([]*campaignRepo)
+	if len(remaining) == 0 {
+		prompt.PrintInfo("Campaign '%s' %scompleted", c.Id, prompt.Color(prompt.FgGreen))
+		return nil
+	}
+
+	prompt.PrintNewLine()
+	prompt.PrintInfo("Campaign '%s' : %d repositories %snot completed", c.Id, len(remaining), prompt.Color(prompt.FgYellow))
+	for _, repo := range remaining {
+		if repo.Error != "" {
+			prompt.PrintItem(repo.Name + " ( " + repo.Step + " : " + repo.Error + " )")
+		} else {
+			prompt.PrintItem(repo.Name + " ( " + repo.Step + " )")
+		}
+	}
+	prompt.PrintInfo("Resume with 'mbx exec --resume %s'", c.Id)
+
+	return nil
+}
+
+// Print running campaign with command to resume it, nothing if no campaign is running
+func PrintInterrupted() {
+
+	c := runningCampaign.Load()
+	if c == nil {
+		return
+	}
+
+	prompt.PrintNewLine()
+	prompt.PrintWarn("Campaign '%s' interrupted", c.Id)
+	prompt.PrintInfo("Resume with 'mbx exec --resume %s'", c.Id)
+}
+
+// Return true if repository has reached step, final steps have reached all of them
+func (r *campaignRepo) reached(step string) bool {
+	if r.finished() {
+		return true
+	}
+	return funk.IndexOfString(campaignSteps, r.Step) >= funk.IndexOfString(campaignSteps, step)
+}
+
+// Return true if nothing is left to do on repository
+func (r *campaignRepo) finished() bool {
+	return r.Step == STEP_REVIEWED || r.Step == STEP_DONE || r.Step == STEP_UNCHANGED
+}
+
+// Review request of campaign repository, as currently known by its remote
+type campaignReview struct {
+	entry  *campaignRepo
+	repo   gitRepository
+	remote *remote
+	review reviewRequest
+	err    error
+}
+
+type campaignStatusRecord struct {
+	Name      string `json:"name" yaml:"name"`
+	Step      string `json:"step" yaml:"step"`
+	Url       string `json:"url,omitempty" yaml:"url,omitempty"`
+	State     string `json:"state,omitempty" yaml:"state,omitempty"`
+	Mergeable string `json:"mergeable,omitempty" yaml:"mergeable,omitempty"`
+	Checks    string `json:"checks,omitempty" yaml:"checks,omitempty"`
+	Approvals int    `json:"approvals" yaml:"approvals"`
+	Error     string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Display repositories of campaign, with state, mergeability, checks and approvals of their review request
+func (g *GitCommands) CampaignStatus(_ context.Context, c *cli.Command) error {
+
+	camp, err := loadCampaign(c.Args().Get(0))
+	if err != nil {
+		prompt.PrintErrorf("Cannot load campaign ( %s )", err.Error())
+		return err
+	}
+
+	reviews, err := g.campaignReviews(camp)
+	if err != nil {
+		prompt.PrintErrorf("Cannot retrieve %s ( %s )", g.GetLabels().RepositoriesLabel, err.Error())
+		return err
+	}
+
+	records := funk.Map(camp.Repositories, func(entry *campaignRepo) campaignStatusRecord {
+		record := campaignStatusRecord{Name: entry.Name, Step: entry.Step, Url: entry.ReviewUrl, Error: entry.Error}
+		if review, ok := reviews[entry.Name]; ok && review.err != nil {
+			record.Error = review.err.Error()
+		} else if ok {
+			record.State = review.review.State
+			record.Mergeable = review.review.Mergeable
+			record.Checks = review.review.Checks
+			record.Approvals = review.review.Approvals
+		}
+		return record
+	}).([]campaignStatusRecord)
+
+	if output.IsStructured() {
+		return output.Print(records)
+	}
+
+	states := map[string]int{}
+	for _, record := range records {
+		if record.State == "" {
+			item := record.Name + " " + prompt.Color(prompt.FgYellow) + "[" + record.Step + "]" + prompt.Color(prompt.Reset)
+			if record.Error != "" {
+				item += " ( " + record.Error + " )"
+			}
+			prompt.PrintItem(item)
+			states["without review"]++
+			continue
+		}
+		states[record.State]++
+		prompt.PrintItem(fmt.Sprintf("%s %s[%s]%s %s , checks %s , %d approvals ( %s )", record.Name, prompt.Color(reviewStateColor(record.State)), record.State, prompt.Color(prompt.Reset), record.Mergeable, record.Checks, record.Approvals, record.Url))
+	}
+
+	counts := []string{}
+	for _, state := range []string{REVIEW_OPEN, REVIEW_DRAFT, REVIEW_MERGED, REVIEW_CLOSED, "without review"} {
+		if states[state] > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", states[state], state))
+		}
+	}
+	prompt.PrintNewLine()
+	prompt.PrintInfo("Campaign '%s' : %d repositories , %s", camp.Id, len(records), strings.Join(counts, " , "))
+
+	return nil
+}
+
+type campaignOperationRecord struct {
+	Name   string `json:"name" yaml:"name"`
+	Url    string `json:"url,omitempty" yaml:"url,omitempty"`
+	Result string `json:"result" yaml:"result"`
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+// Merge open review requests of campaign, with selected strategy
+func (g *GitCommands) CampaignMerge(_ context.Context, c *cli.Command) error {
+
+	camp, err := loadCampaign(c.Args().Get(0))
+	if err != nil {
+		prompt.PrintErrorf("Cannot load campaign ( %s )", err.Error())
+		return err
+	}
+
+	options := funk.Map(MergeStrategies, func(strategy string) prompt.Option { return prompt.Option{Id: strategy, Name: strategy} }).([]prompt.Option)
+	strategy := prompt.Choice("Select merge strategy :", options, c.String("strategy"))
+	if strategy == "" {
+		strategy = MERGE_STRATEGY_MERGE
+	}
+	if !funk.ContainsString(MergeStrategies, strategy) {
+		err := fmt.Errorf("invalid merge strategy '%s', expected one of %s", strategy, strings.Join(MergeStrategies, ", "))
+		prompt.PrintErrorf("%s", err.Error())
+		return err
+	}
+
+	onlyGreen := c.Bool("only-green")
+	deleteBranch := c.Bool("delete-branch")
+
+	skip := func(review *campaignReview) string {
+		if review.review.State != REVIEW_OPEN {
+			return "review request is " + review.review.State
+		}
+		if reason := notGreenReason(review.review); onlyGreen && reason != "" {
+			return reason
+		}
+		return ""
+	}
+	return g.campaignOperation(camp, "merged", "Merge", skip, func(review *campaignReview) error {
+		return review.remote.impl.mergeReviewRequest(&review.repo, review.entry.ReviewId, strategy, deleteBranch)
+	})
+}
+
+// Return why review request is not green ( successful checks, mergeable and approved ), empty if green
+// Unknown mergeability ( not computed yet by provider ) is not green
+func notGreenReason(review reviewRequest) string {
+	if review.Checks != CHECKS_SUCCESS || review.Mergeable != MERGEABLE_YES || review.Approvals == 0 {
+		return fmt.Sprintf("checks %s , %s , %d approvals", review.Checks, review.Mergeable, review.Approvals)
+	}
+	return ""
+}
+
+// Close open and draft review requests of campaign
+func (g *GitCommands) CampaignClose(_ context.Context, c *cli.Command) error {
+
+	camp, err := loadCampaign(c.Args().Get(0))
+	if err != nil {
+		prompt.PrintErrorf("Cannot load campaign ( %s )", err.Error())
+		return err
+	}
+
+	deleteBranch := c.Bool("delete-branch")
+
+	return g.campaignOperation(camp, "closed", "Close", skipNotOpen, func(review *campaignReview) error {
+		return review.remote.impl.closeReviewRequest(&review.repo, review.entry.ReviewId, deleteBranch)
+	})
+}
+
+// Update source branch of open and draft review requests of campaign with their target branch
+func (g *GitCommands) CampaignRebase(_ context.Context, c *cli.Command) error {
+
+	camp, err := loadCampaign(c.Args().Get(0))
+	if err != nil {
+		prompt.PrintErrorf("Cannot load campaign ( %s )", err.Error())
+		return err
+	}
+
+	return g.campaignOperation(camp, "rebased", "", skipNotOpen, func(review *campaignReview) error {
+		return review.remote.impl.updateReviewRequest(&review.repo, review.entry.ReviewId)
+	})
+}
+
+// Return why review request is skipped when it is neither open nor draft, empty otherwise
+func skipNotOpen(review *campaignReview) string {
+	if review.review.State != REVIEW_OPEN && review.review.State != REVIEW_DRAFT {
+		return "review request is " + review.review.State
+	}
+	return ""
+}
+
+// Apply operation on each review request of campaign not skipped, skip returns the reason why a review request is skipped
+// Operation is confirmed once for all review requests it applies on when confirm verb is given ( ex: Merge ), except in non-interactive mode
+func (g *GitCommands) campaignOperation(camp *campaign, done string, confirm string, skip func(review *campaignReview) string, operation func(review *campaignReview) error) error {
+
+	reviews, err := g.campaignReviews(camp)
+	if err != nil {
+		prompt.PrintErrorf("Cannot retrieve %s ( %s )", g.GetLabels().RepositoriesLabel, err.Error())
+		return err
+	}
+
+	count := len(funk.Filter(funk.Values(reviews), func(review *campaignReview) bool { return review.err == nil && skip(review) == "" }).([]*campaignReview))
+	if confirm != "" && count > 0 && config.Options.Interactive {
+		if prompt.RestrictedInput(fmt.Sprintf("%s %d %ss of campaign '%s'?", confirm, count, g.GetLabels().CodeReviewRequest, camp.Id), []string{"y", "n"}) != "y" {
+			prompt.PrintWarn("Campaign '%s' : nothing %s", camp.Id, done)
+			return nil
+		}
+	}
+
+	var records []campaignOperationRecord
+	for _, entry := range camp.Repositories {
+		review, ok := reviews[entry.Name]
+		if !ok {
+			continue
+		}
+
+		record := campaignOperationRecord{Name: entry.Name, Url: entry.ReviewUrl, Result: done}
+		if review.err != nil {
+			record.Result, record.Reason = "failed", review.err.Error()
+		} else if reason := skip(review); reason != "" {
+			record.Result, record.Reason = "skipped", reason
+		} else if err := operation(review); err != nil {
+			record.Result, record.Reason = "failed", err.Error()
+		}
+		records = append(records, record)
+
+		if !output.IsStructured() {
+			printCampaignOperation(record, done)
+		}
+	}
+
+	if output.IsStructured() {
+		return output.Print(records)
+	}
+
+	results := map[string]int{}
+	for _, record := range records {
+		results[record.Result]++
+	}
+	prompt.PrintNewLine()
+	prompt.PrintInfo("Campaign '%s' : %d %s , %d skipped , %d failed", camp.Id, results[done], done, results["skipped"], results["failed"])
+
+	return nil
+}
+
+func printCampaignOperation(record campaignOperationRecord, done string) {
+	switch record.Result {
+	case done:
+		prompt.PrintItem(record.Name + " " + prompt.Color(prompt.FgGreen) + "[" + done + "]" + prompt.Color(prompt.Reset) + " " + record.Url)
+	case "skipped":
+		prompt.PrintItem(record.Name + " " + prompt.Color(prompt.FgYellow) + "[skipped]" + prompt.Color(prompt.Reset) + " ( " + record.Reason + " )")
+	default:
+		prompt.PrintItem(record.Name + " " + prompt.Color(prompt.FgRed) + "[failed]" + prompt.Color(prompt.Reset) + " ( " + record.Reason + " )")
+	}
+}
+
+// Return review requests created by campaign, by repository name
+// Failure to retrieve one review request is kept on it, to not hide the others
+func (g *GitCommands) campaignReviews(camp *campaign) (map[string]*campaignReview, error) {
+
+	reviews := map[string]*campaignReview{}
+	entries := funk.Filter(camp.Repositories, func(entry *campaignRepo) bool { return entry.ReviewId != "" }).([]*campaignRepo)
+	if len(entries) == 0 {
+		return reviews, nil
+	}
+
+	repos, err := g.getRepositories()
+	if err != nil {
+		return nil, err
+	}
+	pathToRepo := funk.Map(repos, func(r gitRepository) (string, gitRepository) { return g.localPath(r), r }).(map[string]gitRepository)
+
+	for _, entry := range entries {
+		review := &campaignReview{entry: entry, repo: pathToRepo[entry.Name]}
+		reviews[entry.Name] = review
+
+		if review.repo.Id == "" {
+			review.err = fmt.Errorf("%s not found on remotes", entry.Name)
+			continue
+		}
+		if review.remote, review.err = g.repoRemote(review.repo); review.err != nil {
+			continue
+		}
+		review.review, review.err = review.remote.impl.getReviewRequest(&review.repo, entry.ReviewId)
+	}
+
+	return reviews, nil
+}
+
+// Return color of review request state
+func reviewStateColor(state string) prompt.Attribute {
+	switch state {
+	case REVIEW_OPEN:
+		return prompt.FgGreen
+	case REVIEW_MERGED:
+		return prompt.FgMagenta
+	case REVIEW_CLOSED:
+		return prompt.FgRed
+	default:
+		return prompt.FgYellow
+	}
+}
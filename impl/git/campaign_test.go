@@ -0,0 +1,84 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vanroy/microcli/impl/action"
+	"github.com/vanroy/microcli/impl/cmd"
+	"github.com/vanroy/microcli/impl/config"
+)
+
+func TestCampaignResume(t *testing.T) {
+
+	root := newLocalRoot(t, "platform/api")
+
+	workspace := t.TempDir()
+	t.Chdir(workspace)
+
+	actionsDir := action.Dir(workspace)
+	os.MkdirAll(actionsDir, 0755)                                                                                                                    //nolint:errcheck
+	os.WriteFile(filepath.Join(actionsDir, "readme"), []byte("#!/bin/sh\ntest -f ../ready || exit 1\necho \"$1\" > README\ngit add README\n"), 0755) //nolint:errcheck
+
+	commands := NewCommands(config.Config{Git: config.GitConfig{Type: "local", BaseUrl: root, GroupIds: []string{"platform"}, CloneProtocol: "ssh"}})
+	repos, err := commands.cloneRepos("", nil, false, 1)
+	if err != nil || len(repos) != 1 {
+		t.Fatalf("unexpected repositories %v ( %v )", repos, err)
+	}
+
+	act, err := action.Load(actionsDir, "readme")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	camp := newCampaign(act.Name)
+	camp.Params = []string{"hello"}
+	camp.Branch = "readme"
+	camp.CommitMessage = "Add README"
+	camp.Repositories = []*campaignRepo{{Name: "api", Step: STEP_PENDING}}
+
+	acceptAll := false
+	commands.execRepo(camp, camp.Repositories[0], repos[0], act, false, &acceptAll)
+
+	saved, err := loadCampaign(camp.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry := saved.Repositories[0]; entry.Step != STEP_INITIALIZED || entry.Error == "" || entry.reached(STEP_EXECUTED) {
+		t.Fatalf("expected failed execution to be recorded, got %+v", entry)
+	}
+
+	os.WriteFile(filepath.Join(workspace, "ready"), nil, 0644) //nolint:errcheck
+	commands.execRepo(saved, saved.Repositories[0], repos[0], act, false, &acceptAll)
+
+	if saved, _ = loadCampaign(camp.Id); saved.Repositories[0].Step != STEP_DONE || saved.Repositories[0].Error != "" {
+		t.Errorf("expected resumed execution to be done, got %+v", saved.Repositories[0])
+	}
+	if _, err := cmd.ExecCmd("git", []string{"-C", filepath.Join(root, "platform", "api.git"), "rev-parse", "--verify", "readme"}); err != nil {
+		t.Errorf("branch of campaign should be pushed")
+	}
+	if branch := getBranch("api"); branch != "main" {
+		t.Errorf("unexpected branch '%s'", branch)
+	}
+}
+
+func TestNotGreenReason(t *testing.T) {
+
+	tests := []struct {
+		review reviewRequest
+		green  bool
+	}{
+		{reviewRequest{Checks: CHECKS_SUCCESS, Mergeable: MERGEABLE_YES, Approvals: 1}, true},
+		{reviewRequest{Checks: CHECKS_SUCCESS, Mergeable: MERGEABLE_UNKNOWN, Approvals: 1}, false},
+		{reviewRequest{Checks: CHECKS_SUCCESS, Mergeable: MERGEABLE_CONFLICT, Approvals: 2}, false},
+		{reviewRequest{Checks: CHECKS_SUCCESS, Mergeable: MERGEABLE_YES, Approvals: 0}, false},
+		{reviewRequest{Checks: CHECKS_PENDING, Mergeable: MERGEABLE_YES, Approvals: 1}, false},
+	}
+
+	for _, test := range tests {
+		if green := notGreenReason(test.review) == ""; green != test.green {
+			t.Errorf("%+v : expected green %t", test.review, test.green)
+		}
+	}
+}
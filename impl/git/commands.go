@@ -2,7 +2,7 @@ package git
 
 import (
 	"context"
-	"encoding/base64"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,41 +11,65 @@ import (
 
 	"github.com/thoas/go-funk"
 	"github.com/urfave/cli/v3"
+	"github.com/vanroy/microcli/impl/action"
 	"github.com/vanroy/microcli/impl/cmd"
 	"github.com/vanroy/microcli/impl/config"
 	"github.com/vanroy/microcli/impl/file"
 	"github.com/vanroy/microcli/impl/glob"
 	"github.com/vanroy/microcli/impl/initialzr"
+	"github.com/vanroy/microcli/impl/output"
 	"github.com/vanroy/microcli/impl/prompt"
+	"github.com/vanroy/microcli/impl/secret"
 )
 
 const (
-	MAX_DEPTH = 2
+	MAX_DEPTH           = 2
+	MAX_NAMESPACE_DEPTH = 20
+	LAYOUT_FLAT         = "flat"
+	LAYOUT_NAMESPACE    = "namespace"
 )
 
 type GitCommands struct {
 	config     config.Config
-	impl       gitRemote
+	remotes    []*remote
 	initializr *initialzr.Initializr
 }
 
 type GitImplement struct {
-	Id   string
-	Name string
-	Impl func(config config.Config) gitRemote
+	Id        string
+	Name      string
+	Impl      func(config config.Config) gitRemote
+	AuthModes []prompt.Option
+}
+
+var patAuthMode = prompt.Option{Id: "pat", Name: "Personal access token"}
+
+// Secret stores able to save token, read-only ones ( env, command ) are configured manually
+var secretStoreOptions = []prompt.Option{
+	{Id: secret.KEYRING, Name: "OS keychain"},
+	{Id: secret.FILE, Name: "Encrypted file ( " + config.SECRETS_FILE + " )"},
+	{Id: secret.GIT_CREDENTIAL, Name: "GIT credential helper"},
 }
 
 var gitImplements = []GitImplement{
-	{Id: "github", Name: "GitHub", Impl: newGitHub},
-	{Id: "gitlab", Name: "GitLab", Impl: newGitLab},
-	{Id: "azure", Name: "Azure DevOps", Impl: newAzure},
+	{Id: "github", Name: "GitHub", Impl: newGitHub, AuthModes: []prompt.Option{patAuthMode, oauthAuthMode, githubAppAuthMode}},
+	{Id: "gitlab", Name: "GitLab", Impl: newGitLab, AuthModes: []prompt.Option{patAuthMode, oauthAuthMode}},
+	{Id: "azure", Name: "Azure DevOps", Impl: newAzure, AuthModes: []prompt.Option{{Id: "az-cli", Name: "Azure CLI"}, patAuthMode}},
+	{Id: "bitbucket", Name: "Bitbucket Server", Impl: newBitbucket, AuthModes: []prompt.Option{patAuthMode}},
+	{Id: "gitea", Name: "Gitea / Forgejo", Impl: newGitea, AuthModes: []prompt.Option{patAuthMode}},
+	{Id: "local", Name: "Local filesystem", Impl: newLocal, AuthModes: []prompt.Option{{Id: "none", Name: "No authentication"}}},
 }
 
 func NewCommands(config config.Config) *GitCommands {
 
+	// Tokens must never be displayed in command output
+	for _, name := range config.RemoteNames() {
+		cmd.AddSecret(config.ForRemote(name).Git.PrivateToken)
+	}
+
 	return &GitCommands{
 		config:     config,
-		impl:       getImpl(config),
+		remotes:    newRemotes(config),
 		initializr: initialzr.NewInitializr(config),
 	}
 }
@@ -53,6 +77,16 @@ func NewCommands(config config.Config) *GitCommands {
 // Init config
 func Init(_ context.Context, c *cli.Command) error {
 
+	// Config is saved in workspace root, init must not override config of a parent workspace
+	workspaceDir, err := config.WorkspaceDir()
+	if err != nil {
+		return err
+	}
+	if currentDir, _ := os.Getwd(); workspaceDir != currentDir {
+		prompt.PrintErrorf("Current folder is inside workspace '%s', run init outside of it", workspaceDir)
+		return fmt.Errorf("already inside workspace '%s'", workspaceDir)
+	}
+
 	typeOptions := funk.Map(gitImplements, func(impl GitImplement) prompt.Option {
 		return prompt.Option{Id: impl.Id, Name: impl.Name}
 	}).([]prompt.Option)
@@ -60,36 +94,62 @@ func Init(_ context.Context, c *cli.Command) error {
 	gitType := prompt.Choice("Select your server type :", typeOptions)
 	prompt.PrintNewLine()
 
-	gitTypeName := getImplCfg(gitType).Name
-	baseUrl := prompt.Input(fmt.Sprintf("Enter your %s base url :", gitTypeName))
+	implCfg := getImplCfg(gitType)
+	baseUrl := prompt.Input(fmt.Sprintf("Enter your %s base url :", implCfg.Name))
 
-	var authMode = "pat"
+	var authMode = implCfg.AuthModes[0].Id
 	var token string
-	if gitType == "azure" {
-		azAuthMode := []prompt.Option{
-			{Id: "az-cli", Name: "Azure CLI"},
-			{Id: "pat", Name: "Personal access token"},
-		}
-		authMode = prompt.Choice("Select your authentication mode :", azAuthMode)
-		if authMode == "pat" {
-			token = prompt.Password(fmt.Sprintf("Enter your %s token :", gitTypeName))
-		}
-	} else {
-		token = prompt.Password(fmt.Sprintf("Enter your %s token :", gitTypeName))
+	var clientId string
+	var secretStore string
+	var appId string
+	var appPrivateKeyPath string
+	if len(implCfg.AuthModes) > 1 {
+		authMode = prompt.Choice("Select your authentication mode :", implCfg.AuthModes)
+	}
+	if authMode == "pat" {
+		token = prompt.Password(fmt.Sprintf("Enter your %s token :", implCfg.Name))
+	}
+	if authMode == OAUTH_AUTH_MODE {
+		clientId = prompt.Input("Enter your OAuth application client id :")
+	}
+	if authMode == GITHUB_APP_AUTH_MODE {
+		appId = prompt.Input("Enter your GitHub App id :")
+		appPrivateKeyPath = prompt.Input("Enter path of your GitHub App private key :")
+	}
+	if authMode == "pat" || authMode == OAUTH_AUTH_MODE {
+		prompt.PrintNewLine()
+		secretStore = prompt.Choice("Select where to store your token :", secretStoreOptions)
 	}
 
 	prompt.PrintNewLine()
 
 	tmpConfig := config.Config{
 		Git: config.GitConfig{
-			Type:         gitType,
-			BaseUrl:      baseUrl,
-			PrivateToken: token,
-			AuthMode:     authMode,
+			Type:              gitType,
+			BaseUrl:           baseUrl,
+			PrivateToken:      token,
+			AuthMode:          authMode,
+			SecretStore:       secretStore,
+			OAuthClientId:     clientId,
+			AppId:             appId,
+			AppPrivateKeyPath: appPrivateKeyPath,
 		},
 	}
 
 	impl := getImpl(tmpConfig)
+
+	if authMode == OAUTH_AUTH_MODE {
+		oauthToken, err := impl.(oauthRemote).getOAuthFlow().deviceLogin(clientId)
+		if err == nil {
+			err = saveOAuthToken(tmpConfig.Git, oauthToken)
+		}
+		if err != nil {
+			prompt.PrintErrorf("Cannot login ( %s )", err.Error())
+			os.Exit(1)
+		}
+		tmpConfig.Git.PrivateToken = oauthToken.AccessToken
+		impl = getImpl(tmpConfig)
+	}
 	groups, err := impl.getGroups()
 	if err != nil {
 		prompt.PrintErrorf("Cannot retrieve %s (%s)", impl.getLabels().GroupsLabel, err.Error())
@@ -105,22 +165,36 @@ func Init(_ context.Context, c *cli.Command) error {
 	}).([]prompt.Option)
 
 	prompt.PrintNewLine()
-	groupId := prompt.Choice("Select group to add on workspace :", groupOptions)
-	prompt.PrintNewLine()
+	var groupIds []string
+	for len(groupIds) == 0 {
+		groupIds = prompt.MultiChoice("Select "+impl.getLabels().GroupsLabel+" to add on workspace :", groupOptions)
+		prompt.PrintNewLine()
+		if len(groupIds) == 0 {
+			prompt.PrintErrorf("At least one %s must be selected", impl.getLabels().GroupLabel)
+			if !config.Options.Interactive {
+				os.Exit(1)
+			}
+		}
+	}
 
-	tmpConfig.Git.GroupIds = []string{groupId}
+	tmpConfig.Git.GroupIds = groupIds
 
 	protocolOptions := []prompt.Option{
 		{Id: "ssh", Name: "SSH"},
 		{Id: "https", Name: "HTTPS"},
 	}
 
-	protocol := prompt.Choice("Select the protocol used for cloning repositories :", protocolOptions)
-	prompt.PrintNewLine()
+	// Local repositories are cloned from their path, protocol doesn't matter
+	protocol := "ssh"
+	if gitType != "local" {
+		protocol = prompt.Choice("Select the protocol used for cloning repositories :", protocolOptions)
+		prompt.PrintNewLine()
+	}
 
 	tmpConfig.Git.CloneProtocol = protocol
 
-	if protocol == "https" {
+	// GIT credential helper already authenticates GIT operations when token is stored in it
+	if protocol == "https" && secretStore != secret.GIT_CREDENTIAL {
 		useTokenOpen := []prompt.Option{
 			{Id: "token", Name: "Use token for GIT operation"},
 			{Id: "cm", Name: "Use GIT integrated authentication ( git-credentials-manager)"},
@@ -130,7 +204,10 @@ func Init(_ context.Context, c *cli.Command) error {
 		tmpConfig.Git.UseTokenForOperation = useToken == "token"
 	}
 
-	config.Save(tmpConfig)
+	if err := config.Save(tmpConfig); err != nil {
+		prompt.PrintErrorf("Cannot save config ( %s )", err.Error())
+		return err
+	}
 
 	return nil
 }
@@ -138,31 +215,144 @@ func Init(_ context.Context, c *cli.Command) error {
 // Refresh authentication config
 func Auth(_ context.Context, c *cli.Command) error {
 
-	existingConfig, _ := config.Load()
+	existingConfig, err := config.Load()
+	if err != nil && !errors.Is(err, config.ErrSecret) {
+		return err
+	}
+
+	name := c.String("remote")
+	if name == "" {
+		name = existingConfig.RemoteNames()[0]
+	}
+	remoteConfig := existingConfig.ForRemote(name)
+
+	implCfg := getImplCfg(remoteConfig.Git.Type)
+	if implCfg == nil {
+		prompt.PrintErrorf("Unknown remote '%s'", name)
+		return fmt.Errorf("unknown remote '%s'", name)
+	}
 
-	token := prompt.Password(fmt.Sprintf("Enter the new %s token :", getImplCfg(existingConfig.Git.Type).Name))
+	if c.Bool("device") {
+		return deviceAuth(&remote{name: name, config: remoteConfig, impl: implCfg.Impl(remoteConfig)})
+	}
+
+	if remoteConfig.Git.AuthMode == GITHUB_APP_AUTH_MODE {
+		prompt.PrintErrorf("GitHub App is authenticated with its private key, update it with 'mbx config set Git.AppPrivateKeyPath'")
+		return errors.New("token not supported by github-app auth mode")
+	}
+
+	token := prompt.Password(fmt.Sprintf("Enter the new %s token :", implCfg.Name))
 	prompt.PrintNewLine()
 
-	existingConfig.Git.PrivateToken = token
+	remoteConfig.Git.PrivateToken = token
+
+	// Bad or under-scoped tokens are reported now, instead of empty listings later
+	if err := checkToken(implCfg.Impl(remoteConfig)); err != nil {
+		if prompt.RestrictedInput("Save token anyway?", []string{"y", "n"}) == "n" {
+			return err
+		}
+	}
+	prompt.PrintNewLine()
+
+	if err := config.SavePassword(remoteConfig.Git); err != nil {
+		prompt.PrintErrorf("Cannot save token ( %s )", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Login with OAuth device flow, remote is switched to OAuth auth mode if required
+func deviceAuth(r *remote) error {
+
+	flowRemote, ok := r.impl.(oauthRemote)
+	if !ok {
+		prompt.PrintErrorf("Device login is not supported by %s", getImplCfg(r.config.Git.Type).Name)
+		return errors.New("device login not supported")
+	}
+
+	clientId := prompt.Input("Enter your OAuth application client id :", r.config.Git.OAuthClientId)
+	prompt.PrintNewLine()
+
+	token, err := flowRemote.getOAuthFlow().deviceLogin(clientId)
+	if err != nil {
+		prompt.PrintErrorf("Cannot login ( %s )", err.Error())
+		return err
+	}
 
-	config.SavePassword(existingConfig.Git)
+	if err := saveOAuthToken(r.config.Git, token); err != nil {
+		prompt.PrintErrorf("Cannot save token ( %s )", err.Error())
+		return err
+	}
+
+	prompt.PrintInfo("Logged in with %sOAuth", prompt.Color(prompt.FgGreen))
+
+	loggedConfig := r.config
+	loggedConfig.Git.AuthMode = OAUTH_AUTH_MODE
+	loggedConfig.Git.OAuthClientId = clientId
+	loggedConfig.Git.PrivateToken = token.AccessToken
+	checkToken(getImpl(loggedConfig)) //nolint:errcheck
+
+	if r.config.Git.AuthMode == OAUTH_AUTH_MODE && r.config.Git.OAuthClientId == clientId {
+		return nil
+	}
+
+	return updateConfig(func(conf *config.Config) error {
+		remoteConf := workspaceRemote(conf, r)
+		remoteConf.AuthMode = OAUTH_AUTH_MODE
+		remoteConf.OAuthClientId = clientId
+		return nil
+	})
+}
+
+// Print identity, scopes and expiry of token, with features not allowed by its scopes
+func checkToken(impl gitRemote) error {
+
+	info, err := impl.getTokenInfo()
+	if err != nil {
+		prompt.PrintErrorf("Token is invalid ( %s )", err.Error())
+		return err
+	}
+
+	prompt.PrintInfo("Authenticated as %s%s", prompt.Color(prompt.FgGreen), info.User)
+	if len(info.Scopes) > 0 {
+		prompt.PrintInfo("Scopes : %s", strings.Join(info.Scopes, ", "))
+	}
+	if info.ExpiresAt != "" {
+		prompt.PrintInfo("Expires at : %s", info.ExpiresAt)
+	}
+	for _, missing := range info.Missing {
+		prompt.PrintWarn("Missing scope %s, required by %s", strings.Join(missing.Scopes, " or "), missing.Usage)
+	}
 
 	return nil
 }
 
-// Return impl labels
+// Return impl labels, generic ones if workspace mix remotes of different types
 func (g *GitCommands) GetLabels() Labels {
-	return g.impl.getLabels()
+
+	for _, r := range g.remotes[1:] {
+		if !strings.EqualFold(r.config.Git.Type, g.remotes[0].config.Git.Type) {
+			return genericLabels
+		}
+	}
+
+	return g.remotes[0].impl.getLabels()
 }
 
 // Return list of GIT repository present in local folder
 func (g *GitCommands) ListLocal(_ context.Context, c *cli.Command) error {
 
-	folders, err := getGitFolders("", c.StringArgs("exclude"))
+	folders, err := g.getGitFolders(g.defaultGlob(""), c.StringArgs("exclude"))
 	if err != nil {
 		return err
 	}
 
+	if output.IsStructured() {
+		records := funk.Map(folders, func(folder string) repoRecord { return g.toLocalRecord(folder, false) }).([]repoRecord)
+		return output.Print(records)
+	}
+
 	if len(folders) == 0 {
 		fmt.Printf("No ")
 	}
@@ -177,13 +367,25 @@ func (g *GitCommands) ListLocal(_ context.Context, c *cli.Command) error {
 // Return list of GIT repository present on remote server
 func (g *GitCommands) ListRemote(_ context.Context, c *cli.Command) error {
 
-	repos, err := g.impl.getRepositories()
+	repos, err := g.getRepositories()
+
+	if output.IsStructured() {
+		if err != nil {
+			return err
+		}
+		return output.Print(funk.Map(repos, g.toRecord).([]repoRecord))
+	}
+
 	if err != nil || len(repos) == 0 {
 		prompt.PrintErrorf("No project found")
 	}
 
 	funk.ForEach(repos, func(repo gitRepository) {
-		prompt.PrintItem(repo.NameWithNamespace + " ( " + strings.ReplaceAll(repo.Description, "\n", " ") + " )")
+		if len(g.remotes) > 1 {
+			prompt.PrintItem("[" + repo.Remote + "] " + repo.NameWithNamespace + " ( " + strings.ReplaceAll(repo.Description, "\n", " ") + " )")
+		} else {
+			prompt.PrintItem(repo.NameWithNamespace + " ( " + strings.ReplaceAll(repo.Description, "\n", " ") + " )")
+		}
 	})
 
 	return nil
@@ -197,46 +399,76 @@ func (g *GitCommands) Clone(_ context.Context, c *cli.Command) error {
 		globStr = c.Args().Get(0)
 	}
 
-	_, error := g.cloneRepos(globStr, c.StringSlice("exclude"), true)
+	_, error := g.cloneRepos(globStr, c.StringSlice("exclude"), true, g.jobs(c))
 	return error
 }
 
 // PULL + REBASE all local GIT repository
 func (g *GitCommands) Up(_ context.Context, c *cli.Command) error {
 
-	folders, err := getGitFolders(c.Args().Get(0), c.StringSlice("exclude"))
+	folders, err := g.getGitFolders(g.defaultGlob(c.Args().Get(0)), c.StringSlice("exclude"))
 	if err != nil {
 		return err
 	}
 
-	funk.ForEach(folders, func(folder string) {
+	results := runJobs(g.jobs(c), folders, func(p *prompt.Printer, folder string) error {
 
-		args := g.authorization()
+		args := g.authorization(g.folderRemote(folder))
 		args = append(args, []string{"-C", file.Rel(folder), "pull", "-q", "--rebase"}...)
 		if c.Bool("stash") {
 			args = append(args, "--autostash")
 		}
 
 		if out, err := cmd.ExecCmd("git", args); err != nil {
-			prompt.PrintWarn("Cannot update '%s' ( %s )", folder, cmd.ErrorString(out))
-			return
+			p.PrintWarn("Cannot update '%s' ( %s )", folder, cmd.ErrorString(out))
+			return errors.New(cmd.ErrorString(out))
 		} else {
-			prompt.PrintInfo("'%s' %supdated", folder, prompt.Color(prompt.FgBlue))
+			p.PrintInfo("'%s' %supdated", folder, prompt.Color(prompt.FgBlue))
 		}
+
+		return nil
 	})
 
+	printJobsSummary(results)
+
 	return nil
 }
 
 // Display status for all local GIT repository
 func (g *GitCommands) St(_ context.Context, c *cli.Command) error {
 
-	folders, err := getGitFolders(c.Args().Get(0), c.StringArgs("exclude"))
+	folders, err := g.getGitFolders(g.defaultGlob(c.Args().Get(0)), c.StringArgs("exclude"))
 	if err != nil {
 		return err
 	}
 
-	funk.ForEach(folders, g.status)
+	filters := c.StringSlice("only")
+	for _, filter := range filters {
+		if !funk.ContainsString(statusFilters, filter) {
+			prompt.PrintErrorf("Invalid filter '%s', expected one of %s", filter, strings.Join(statusFilters, ", "))
+			return fmt.Errorf("invalid filter '%s'", filter)
+		}
+	}
+
+	records := make([]*repoRecord, len(folders))
+	results := runJobs(g.jobs(c), folders, func(p *prompt.Printer, folder string) error {
+		record := g.toLocalRecord(folder, true)
+		if !record.matchFilters(filters) {
+			return nil
+		}
+
+		records[funk.IndexOfString(folders, folder)] = &record
+		if output.IsStructured() {
+			return nil
+		}
+		return g.printStatus(p, record)
+	})
+
+	if output.IsStructured() {
+		return output.Print(funk.Compact(records))
+	}
+
+	printJobsSummary(results)
 
 	return nil
 }
@@ -244,7 +476,12 @@ func (g *GitCommands) St(_ context.Context, c *cli.Command) error {
 // Add new ( group / orga / project ) on remote hosting
 func (g *GitCommands) AddGroup(_ context.Context, c *cli.Command) error {
 
-	_, err := g.impl.createGroup(c.Args())
+	r, err := g.selectRemote(c)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.impl.createGroup(c.Args())
 	if err != nil {
 		prompt.PrintErrorf("Cannot create group, error : %s", err.Error())
 	}
@@ -255,7 +492,12 @@ func (g *GitCommands) AddGroup(_ context.Context, c *cli.Command) error {
 // Add new GIT repository on remote hosting
 func (g *GitCommands) Add(_ context.Context, c *cli.Command) error {
 
-	id, err := g.impl.createRepository(c.Args())
+	r, err := g.selectRemote(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := r.impl.createRepository(c.Args())
 	if err != nil {
 		return err
 	}
@@ -275,23 +517,24 @@ func (g *GitCommands) Add(_ context.Context, c *cli.Command) error {
 		pDeps := prompt.Input("\nEnter your project initializr dependencies ( separated with comma ) :", c.String("dependencies"))
 
 		// Clone new repository
-		repos, err := g.impl.getRepositories()
+		repos, err := r.impl.getRepositories()
 		if err != nil || len(repos) == 0 {
 			prompt.PrintError("No project found")
 			return err
 		}
 
 		repo := funk.Find(repos, func(repo gitRepository) bool { return repo.Id == id }).(gitRepository)
+		repo.Remote = r.name
 
-		_, cloneErr := g.clone(g.computeCloneUrl(repo), repo.Path)
+		_, cloneErr := g.clone(r, g.computeCloneUrl(repo), g.localPath(repo))
 		if cloneErr != nil {
 			return cloneErr
 		}
 
 		// Init repo
-		initErr := g.initRepo(repo.Path, pType, pName, pDeps)
+		initErr := g.initRepo(g.localPath(repo), pType, pName, pDeps)
 		if initErr != nil {
-			prompt.PrintErrorf("Cannot init repository, error : %s", err.Error())
+			prompt.PrintErrorf("Cannot init repository, error : %s", initErr.Error())
 		}
 	}
 
@@ -313,171 +556,315 @@ func (g *GitCommands) Init(_ context.Context, c *cli.Command) error {
 // Execute scripts on repositories
 func (g *GitCommands) Exec(_ context.Context, c *cli.Command) error {
 
-	pGlob := prompt.Input("\nEnter your project filter :", c.Args().Get(0))
-	pAction := prompt.Input("\nEnter your action name :", c.Args().Get(1))
+	if c.Bool("list") {
+		return listActions()
+	}
+
+	var camp *campaign
+	var act *action.Action
+	var err error
+	workspaceDir, _ := config.WorkspaceDir()
+
+	if id := c.String("resume"); id != "" {
+		if camp, err = loadCampaign(id); err != nil {
+			prompt.PrintErrorf("Cannot resume campaign ( %s )", err.Error())
+			return err
+		}
+		if act, err = action.Load(action.Dir(workspaceDir), camp.Action); err != nil {
+			prompt.PrintErrorf("Cannot load action ( %s )", err.Error())
+			return err
+		}
+	} else if camp, act, err = g.promptCampaign(c, workspaceDir); err != nil {
+		return err
+	}
 
-	acceptedInputs := []string{"y", "n", "a", "q"}
-	acceptAll := false
 	isInteractive := c.Bool("interactive")
-	branchName := c.String("branch")
-	commitMessage := c.String("commit-message")
+	dryRun := c.Bool("dry-run")
 
-	review := c.Bool("review")
-	reviewTitle := c.String("review-title")
-	if len(reviewTitle) == 0 {
-		reviewTitle = commitMessage
+	// Start by cloning matching repositories, actions are then executed one repository at a time
+	repos, err := g.cloneRepos(camp.Glob, camp.Exclude, false, g.jobs(c))
+	if err != nil {
+		prompt.PrintErrorf("Cannot clone repositories (%s)", err.Error())
+		return err
+	}
+
+	pathToRepo := funk.Map(repos, func(r gitRepository) (string, gitRepository) { return g.localPath(r), r }).(map[string]gitRepository)
+
+	if c.String("resume") == "" {
+		folders, _ := g.getGitFolders(camp.Glob, camp.Exclude)
+		for _, folder := range folders {
+			if !act.Applies(folder) {
+				prompt.PrintInfo("Skipping action for '%s' : %snot applicable", folder, prompt.Color(prompt.FgYellow))
+				continue
+			}
+			camp.Repositories = append(camp.Repositories, &campaignRepo{Name: folder, Step: STEP_PENDING, UpdatedAt: camp.CreatedAt})
+		}
+	}
+
+	if dryRun {
+		records := funk.Map(camp.Repositories, func(entry *campaignRepo) dryRunRecord {
+			repo := pathToRepo[entry.Name]
+			defaultBranch := g.repoDefaultBranch(entry.Name, repo)
+			record := dryRunRecord{Name: entry.Name, Branch: camp.Branch, Commit: camp.CommitMessage}
+			if camp.reviewable(repo, defaultBranch) {
+				record.Review = camp.ReviewTitle
+			}
+			return g.dryRun(entry.Name, defaultBranch, act, camp.Params, record)
+		}).([]dryRunRecord)
+		return printDryRunSummary(records)
+	}
+
+	if err := camp.save(); err != nil {
+		prompt.PrintErrorf("Cannot save campaign ( %s )", err.Error())
+		return err
+	}
+	prompt.PrintInfo("Executing campaign '%s'", camp.Id)
+	runningCampaign.Store(camp)
+	defer runningCampaign.Store(nil)
+
+	acceptAll := false
+	for _, entry := range camp.Repositories {
+		if entry.finished() {
+			continue
+		}
+		if err := g.execRepo(camp, entry, pathToRepo[entry.Name], act, isInteractive, &acceptAll); err != nil {
+			printCampaignResult(camp) //nolint:errcheck
+			return err
+		}
+	}
+
+	return printCampaignResult(camp)
+}
+
+// Return new campaign from command arguments and flags, prompting for missing ones
+func (g *GitCommands) promptCampaign(c *cli.Command, workspaceDir string) (*campaign, *action.Action, error) {
+
+	pGlob := prompt.Input("\nEnter your project filter :", g.defaultGlob(c.Args().Get(0)))
+	pAction := prompt.Input("\nEnter your action name :", c.Args().Get(1))
+
+	act, err := action.Load(action.Dir(workspaceDir), pAction)
+	if err != nil {
+		prompt.PrintErrorf("Cannot load action ( %s )", err.Error())
+		return nil, nil, err
 	}
-	reviewMessage := c.String("review-message")
-	reviewDraft := c.Bool("review-draft")
 
 	pParams := []string{}
-	if c.Args().Len() < 2 {
+	values := map[string]string{}
+	if act.Manifest != "" {
+		if pParams, err = promptActionParams(act, c.Args().Slice()[min(2, c.Args().Len()):], values); err != nil {
+			prompt.PrintErrorf("Invalid action parameters ( %s )", err.Error())
+			return nil, nil, err
+		}
+	} else if c.Args().Len() < 2 {
 		pParams = strings.Split(prompt.Input("\nEnter your action parameters :"), " ")
 	} else {
 		pParams = c.Args().Slice()[2:]
 	}
 
-	// Start by cloning matching repositories
-	repos, err := g.cloneRepos(pGlob, c.StringArgs("exclude"), false)
-	if err != nil {
-		prompt.PrintErrorf("Cannot clone repositories (%s)", err.Error())
-		return err
+	// Flags take precedence over templates of action manifest
+	templates := map[string]string{"branch": act.Branch, "commit-message": act.CommitMessage, "review-title": act.ReviewTitle, "review-message": act.ReviewMessage}
+	for flag, text := range templates {
+		if c.String(flag) != "" || text == "" {
+			templates[flag] = c.String(flag)
+			continue
+		}
+		if templates[flag], err = act.Render(text, values); err != nil {
+			prompt.PrintErrorf("Cannot render %s of action ( %s )", flag, err.Error())
+			return nil, nil, err
+		}
 	}
 
-	pathToRepo := funk.Map(repos, func(r gitRepository) (string, gitRepository) { return r.Path, r }).(map[string]gitRepository)
+	camp := newCampaign(act.Name)
+	camp.Glob = pGlob
+	camp.Exclude = c.StringArgs("exclude")
+	camp.Params = pParams
+	camp.Branch = templates["branch"]
+	camp.CommitMessage = templates["commit-message"]
+	camp.Review = c.Bool("review")
+	camp.ReviewTitle = templates["review-title"]
+	if len(camp.ReviewTitle) == 0 {
+		camp.ReviewTitle = camp.CommitMessage
+	}
+	camp.ReviewMessage = templates["review-message"]
+	camp.ReviewDraft = c.Bool("review-draft")
 
-	folders, _ := getGitFolders(pGlob, c.StringArgs("exclude"))
-	funk.ForEach(folders, func(folder string) {
+	return camp, act, nil
+}
 
-		repo := pathToRepo[folder]
+// Execute action on repository of campaign, from its last reached step
+// Campaign is saved after each step, a failed or declined step is resumed by next execution
+// errCampaignQuit is returned when user quits campaign at a prompt
+func (g *GitCommands) execRepo(camp *campaign, entry *campaignRepo, repo gitRepository, act *action.Action, isInteractive bool, acceptAll *bool) error {
 
-		defaultBranch := getBranch(folder)
-		if repo.Id != "" {
-			defaultBranch = repo.DefaultBranch
+	folder := entry.Name
+	defaultBranch := g.repoDefaultBranch(folder, repo)
+	acceptedInputs := []string{"y", "n", "a", "q"}
+
+	setStep := func(step string) {
+		if err := camp.setStep(entry, step); err != nil {
+			prompt.PrintErrorf("Cannot save campaign '%s' ( %s )", camp.Id, err.Error())
+		}
+	}
+	setError := func(err string) {
+		if saveErr := camp.setError(entry, err); saveErr != nil {
+			prompt.PrintErrorf("Cannot save campaign '%s' ( %s )", camp.Id, saveErr.Error())
+		}
+	}
+	var quit error
+	approve := func(message string) bool {
+		if !isInteractive || *acceptAll {
+			return true
 		}
+		switch prompt.RestrictedInput(message, acceptedInputs) {
+		case "q":
+			quit = errCampaignQuit
+			return false
+		case "n":
+			return false
+		case "a":
+			*acceptAll = true
+		}
+		return true
+	}
+	finish := func(step string) {
+		setStep(step)
+		// Return to default branch
+		if len(camp.Branch) > 0 {
+			checkout(folder, defaultBranch)
+		}
+		prompt.PrintInfo("Executed action for '%s' with %ssuccess", folder, prompt.Color(prompt.FgGreen))
+	}
+
+	prompt.PrintInfo("Executing action for '%s'", folder)
 
-		prompt.PrintInfo("Executing action for '%s'", folder)
+	if !entry.reached(STEP_EXECUTED) {
 
 		// Cleanup ( defaultBranch )
 		prompt.PrintInfo("Executing action for '%s' : %sinitializing", folder, prompt.Color(prompt.FgYellow))
 		g.cleanup(folder, defaultBranch)
 
 		// Check branch if required
-		if len(branchName) > 0 {
-			branch(folder, branchName)
-		}
-
-		if isInteractive && !acceptAll {
-			response := prompt.RestrictedInput("Initialization done, continue to execute?", acceptedInputs)
-			switch response {
-			case "q":
-				os.Exit(0)
-			case "n":
-				return
-			case "a":
-				acceptAll = true
-			}
+		if len(camp.Branch) > 0 {
+			branch(folder, camp.Branch)
+		}
+		setStep(STEP_INITIALIZED)
+
+		if !approve("Initialization done, continue to execute?") {
+			return quit
 		}
 
 		// Execute action
 		prompt.PrintInfo("Executing action for '%s' : %sexecuting", folder, prompt.Color(prompt.FgYellow))
-		out, err := g.exec(folder, pAction, pParams)
+		out, err := g.exec(folder, act, camp.Params)
 		if err != nil {
 			prompt.PrintErrorf("Cannot execute action for %s , error : %s \n%s", folder, err.Error(), out)
-			return
+			setError(err.Error() + " " + cmd.ErrorString(out))
+			return nil
 		}
+		setStep(STEP_EXECUTED)
+
+	} else if len(camp.Branch) > 0 {
+		// Resume on branch of campaign, changes of previous execution are kept
+		checkout(folder, camp.Branch)
+	}
+
+	if !entry.reached(STEP_COMMITTED) {
 
-		if checkUncachedUncommitted(folder) {
+		if !hasTrackedChanges(file.Rel(folder)) {
 			prompt.PrintInfo("Executing action for '%s' : %snothing to commit", folder, prompt.Color(prompt.FgYellow))
-		} else if len(commitMessage) > 0 {
-
-			if isInteractive && !acceptAll {
-
-				for {
-					response := prompt.RestrictedInput("Execution done, continue to commit?", append(acceptedInputs, "d"))
-					if response == "y" {
-						break
-					} else if response == "q" {
-						os.Exit(0)
-					} else if response == "n" {
-						return
-					} else if response == "a" {
-						acceptAll = true
-						break
-					} else if response == "d" {
-						err := displayDiff(folder)
-						if err != nil {
-							prompt.PrintErrorf("Cannot execute diff for %s , error : %s \n", folder, err.Error())
-						}
-					}
-				}
-			}
+			finish(STEP_UNCHANGED)
+			return nil
+		} else if len(camp.CommitMessage) == 0 {
+			finish(STEP_DONE)
+			return nil
+		}
 
-			prompt.PrintInfo("Executing action for '%s' : %scommitting", folder, prompt.Color(prompt.FgYellow))
-			addAndCommit(folder, commitMessage, true)
-
-			if isInteractive && !acceptAll {
-				response := prompt.RestrictedInput("Commit done, continue to push?", acceptedInputs)
-				switch response {
-				case "q":
-					os.Exit(0)
-				case "n":
-					return
-				case "a":
-					acceptAll = true
+		if isInteractive && !*acceptAll {
+			for {
+				response := prompt.RestrictedInput("Execution done, continue to commit?", append(acceptedInputs, "d"))
+				if response == "y" {
+					break
+				} else if response == "q" {
+					return errCampaignQuit
+				} else if response == "n" {
+					return nil
+				} else if response == "a" {
+					*acceptAll = true
+					break
+				} else if response == "d" {
+					err := displayDiff(folder)
+					if err != nil {
+						prompt.PrintErrorf("Cannot execute diff for %s , error : %s \n", folder, err.Error())
+					}
 				}
 			}
+		}
 
-			prompt.PrintInfo("Executing action for '%s' : %spushing", folder, prompt.Color(prompt.FgYellow))
-			g.push(folder, branchName)
+		prompt.PrintInfo("Executing action for '%s' : %scommitting", folder, prompt.Color(prompt.FgYellow))
+		if err := addAndCommit(folder, camp.CommitMessage, true); err != nil {
+			prompt.PrintErrorf("Cannot commit for '%s' , error : %s", folder, err.Error())
+			setError(err.Error())
+			return nil
+		}
+		setStep(STEP_COMMITTED)
+	}
 
-			if repo.Id != "" && len(branchName) > 0 && branchName != defaultBranch && review && len(reviewTitle) > 0 {
+	if !entry.reached(STEP_PUSHED) {
 
-				if isInteractive && !acceptAll {
-					response := prompt.RestrictedInput("Push done, continue to create "+g.GetLabels().CodeReviewRequest+"?", acceptedInputs)
+		if !approve("Commit done, continue to push?") {
+			return quit
+		}
 
-					switch response {
-					case "q":
-						os.Exit(0)
-					case "n":
-						return
-					case "a":
-						acceptAll = true
-					}
-				}
+		prompt.PrintInfo("Executing action for '%s' : %spushing", folder, prompt.Color(prompt.FgYellow))
+		if err := g.push(folder, camp.Branch); err != nil {
+			prompt.PrintErrorf("Cannot push for '%s' , error : %s", folder, err.Error())
+			setError(err.Error())
+			return nil
+		}
+		setStep(STEP_PUSHED)
+	}
 
-				prompt.PrintInfo("Executing action for '%s' : %screating %s", folder, prompt.Color(prompt.FgYellow), g.GetLabels().CodeReviewRequest)
-				review, err := g.impl.createReviewRequest(&repo, branchName, defaultBranch, reviewTitle, reviewMessage, reviewDraft)
-				if err != nil {
-					prompt.PrintErrorf("Cannot create %s for '%s' : , error : %s", g.GetLabels().CodeReviewRequest, folder, err.Error())
-				} else {
-					prompt.PrintInfo("Succeeded to create %s for '%s' , URL: %s%s", g.GetLabels().CodeReviewRequest, folder, prompt.Color(prompt.FgBlue), review.Url)
-				}
-			}
+	if !camp.reviewable(repo, defaultBranch) {
+		finish(STEP_DONE)
+		return nil
+	}
 
-		}
+	r, err := g.repoRemote(repo)
+	if err != nil {
+		prompt.PrintErrorf("Cannot create review request for '%s' : , error : %s", folder, err.Error())
+		setError(err.Error())
+		return nil
+	}
+	reviewLabel := r.impl.getLabels().CodeReviewRequest
 
-		// Return to default branch
-		if len(branchName) > 0 {
-			checkout(folder, defaultBranch)
-		}
+	if !approve("Push done, continue to create " + reviewLabel + "?") {
+		return quit
+	}
 
-		prompt.PrintInfo("Executed action for '%s' with %ssuccess", folder, prompt.Color(prompt.FgGreen))
+	prompt.PrintInfo("Executing action for '%s' : %screating %s", folder, prompt.Color(prompt.FgYellow), reviewLabel)
+	review, err := r.impl.createReviewRequest(&repo, camp.Branch, defaultBranch, camp.ReviewTitle, camp.ReviewMessage, camp.ReviewDraft)
+	if err != nil {
+		prompt.PrintErrorf("Cannot create %s for '%s' : , error : %s", reviewLabel, folder, err.Error())
+		setError(err.Error())
+		return nil
+	}
+	prompt.PrintInfo("Succeeded to create %s for '%s' , URL: %s%s", reviewLabel, folder, prompt.Color(prompt.FgBlue), review.Url)
 
-	})
+	entry.ReviewId, entry.ReviewUrl = review.Id, review.Url
+	finish(STEP_REVIEWED)
 
 	return nil
 }
 
 // Execute scripts one repository
-func (g *GitCommands) exec(folder string, action string, params []string) (string, error) {
-	dir, _ := os.Getwd()
-	return cmd.ExecCmdDir(dir+"/.microbox/actions/"+action, params, dir+"/"+folder)
+func (g *GitCommands) exec(folder string, act *action.Action, params []string) (string, error) {
+	dir, _ := config.WorkspaceDir()
+	return cmd.ExecCmdDir(act.Path, params, dir+"/"+folder)
 }
 
 // Clone GIT repository matching with pattern
-func (g *GitCommands) cloneRepos(globStr string, exclusion []string, output bool) ([]gitRepository, error) {
-	repos, err := g.impl.getRepositories()
+func (g *GitCommands) cloneRepos(globStr string, exclusion []string, output bool, jobs int) ([]gitRepository, error) {
+	repos, err := g.getRepositories()
 	if err != nil || len(repos) == 0 {
 		if output {
 			prompt.PrintErrorf("No project found")
@@ -486,9 +873,14 @@ func (g *GitCommands) cloneRepos(globStr string, exclusion []string, output bool
 
 	var matcher = glob.NewGlobMatcher(globStr, exclusion...)
 
+	var toClone []string
+	pathToRepo := map[string]gitRepository{}
+
 	funk.ForEach(repos, func(repo gitRepository) {
 
-		match, reason := matcher.Match(repo.Path)
+		path := g.localPath(repo)
+
+		match, reason := matcher.Match(path)
 		if !match {
 			if output {
 				prompt.PrintInfo("%s %sskipping", reason, prompt.Color(prompt.FgYellow))
@@ -496,28 +888,49 @@ func (g *GitCommands) cloneRepos(globStr string, exclusion []string, output bool
 			return
 		}
 
-		if file.Exist(repo.Path) {
+		if file.Exist(path) {
 			if output {
 				prompt.PrintInfo("'%s' already existing, %sskipping", repo.Name, prompt.Color(prompt.FgBlue))
 			}
 		} else {
+			toClone = append(toClone, path)
+			pathToRepo[path] = repo
+		}
+	})
 
-			if output {
-				prompt.PrintInfo("'%s' not existing, %scloning%s into '%s'", repo.Name, prompt.Color(prompt.FgGreen), prompt.Color(prompt.FgWhite), repo.Path)
-			}
+	results := runJobs(jobs, toClone, func(p *prompt.Printer, path string) error {
 
-			if out, err := g.clone(g.computeCloneUrl(repo), repo.Path); err != nil {
-				prompt.PrintErrorf("Cannot clone ( %s )", err)
-			} else {
-				if checkIsRepoEmptyErr(out) {
-					if output {
-						prompt.PrintWarn("Repository is empty")
-					}
-				}
+		repo := pathToRepo[path]
+
+		if output {
+			p.PrintInfo("'%s' not existing, %scloning%s into '%s'", repo.Name, prompt.Color(prompt.FgGreen), prompt.Color(prompt.FgWhite), path)
+		}
+
+		r, err := g.repoRemote(repo)
+		if err != nil {
+			p.PrintErrorf("Cannot clone ( %s )", err)
+			return err
+		}
+
+		out, err := g.clone(r, g.computeCloneUrl(repo), path)
+		if err != nil {
+			p.PrintErrorf("Cannot clone ( %s )", err)
+			return err
+		}
+
+		if checkIsRepoEmptyErr(out) {
+			if output {
+				p.PrintWarn("Repository is empty")
 			}
 		}
+
+		return nil
 	})
 
+	if output {
+		printJobsSummary(results)
+	}
+
 	return repos, nil
 }
 
@@ -531,62 +944,75 @@ func (g *GitCommands) initRepo(folder string, projectType string, projectName st
 	}
 
 	// Commit
-	addAndCommit(folder, "Initial commit", false)
+	if err := addAndCommit(folder, "Initial commit", false); err != nil {
+		return err
+	}
 
 	// Push
-	g.push(folder, "")
-
-	return nil
+	return g.push(folder, "")
 }
 
-// Display status for local GIT repository
-func (g *GitCommands) status(folder string) {
-
-	repoUrl := getRepoUrl(folder)
+// Display status of local GIT repository record
+func (g *GitCommands) printStatus(p *prompt.Printer, record repoRecord) error {
 
-	currentBranch := fmt.Sprintf("%.25s", getBranch(folder))
+	folder := record.Path
+	currentBranch := fmt.Sprintf("%.25s", record.CurrentBranch)
 
 	var localInfo string
-	if repoUrl == "" {
+	if record.OriginUrl == "" {
 		localInfo = fmt.Sprintf(" %s[Local only repository]", prompt.Color(prompt.FgBlue))
 	}
 
-	if !checkUncachedUncommitted(folder) {
-		prompt.PrintInfo("'%s' -> %s %sDirty (Uncached changes)%s", folder, currentBranch, prompt.Color(prompt.FgYellow), localInfo)
-	} else if !checkCachedUncommitted(folder) {
-		prompt.PrintInfo("'%s' -> %s %sDirty (Uncommitted changes)%s", folder, currentBranch, prompt.Color(prompt.FgYellow), localInfo)
-	} else if !checkUntracked(folder) {
-		prompt.PrintInfo("'%s' -> %s %sDirty (Untracked changes)%s", folder, currentBranch, prompt.Color(prompt.FgYellow), localInfo)
-	} else if repoUrl == "" {
+	var extraInfo string
+	if record.Operation != "" {
+		extraInfo += fmt.Sprintf(" %s[%s in progress]", prompt.Color(prompt.FgRed), strings.ToUpper(record.Operation[:1])+record.Operation[1:])
+	} else if record.Detached {
+		extraInfo += fmt.Sprintf(" %s[Detached HEAD]", prompt.Color(prompt.FgRed))
+	}
+	if record.Stashes > 0 {
+		extraInfo += fmt.Sprintf(" %s[%d stashed]", prompt.Color(prompt.FgCyan), record.Stashes)
+	}
+
+	switch {
+	case record.DirtyReason == DIRTY_UNCACHED:
+		p.PrintInfo("'%s' -> %s %sDirty (Uncached changes)%s%s", folder, currentBranch, prompt.Color(prompt.FgYellow), localInfo, extraInfo)
+	case record.DirtyReason == DIRTY_UNCOMMITTED:
+		p.PrintInfo("'%s' -> %s %sDirty (Uncommitted changes)%s%s", folder, currentBranch, prompt.Color(prompt.FgYellow), localInfo, extraInfo)
+	case record.DirtyReason == DIRTY_UNTRACKED:
+		p.PrintInfo("'%s' -> %s %sDirty (Untracked changes)%s%s", folder, currentBranch, prompt.Color(prompt.FgYellow), localInfo, extraInfo)
+	case record.Sync == SYNC_LOCAL_ONLY:
 		/*
 			If the "origin" URL is not defined in the project list, then no need
 			to check for synchronization. It is clean if there is no untracked,
 			uncached or uncommitted changes.
 		*/
-		prompt.PrintInfo("'%s' -> %s %sClean %s", folder, currentBranch, prompt.Color(prompt.FgGreen), localInfo)
-	} else {
-
-		// Fetch from remote
-		g.fetch(folder)
-
-		// Check for diverged branches
-		local, remote := checkBranchOrigin(folder, currentBranch)
-
-		if remote == "" {
-			prompt.PrintInfo("'%s' -> %s %sNo remote branch", folder, currentBranch, prompt.Color(prompt.FgYellow))
-		} else if local == "" {
-			prompt.PrintInfo("'%s' -> %s %sInternal error", folder, currentBranch, prompt.Color(prompt.FgRed))
-		} else if local != remote {
-			prompt.PrintInfo("'%s' -> %s %sNot in sync", folder, currentBranch, prompt.Color(prompt.FgYellow))
-		} else if local == remote {
-			prompt.PrintInfo("'%s' -> %s %sClean", folder, currentBranch, prompt.Color(prompt.FgGreen))
-		}
+		p.PrintInfo("'%s' -> %s %sClean %s%s", folder, currentBranch, prompt.Color(prompt.FgGreen), localInfo, extraInfo)
+	case record.Sync == SYNC_DETACHED:
+		p.PrintInfo("'%s' -> %s %sClean%s", folder, currentBranch, prompt.Color(prompt.FgGreen), extraInfo)
+	case record.Sync == SYNC_NO_REMOTE_BRANCH:
+		p.PrintInfo("'%s' -> %s %sNo remote branch%s", folder, currentBranch, prompt.Color(prompt.FgYellow), extraInfo)
+	case record.Sync == SYNC_ERROR:
+		p.PrintInfo("'%s' -> %s %sInternal error", folder, currentBranch, prompt.Color(prompt.FgRed))
+		return errors.New("cannot resolve local branch")
+	case record.Sync == SYNC_AHEAD:
+		p.PrintInfo("'%s' -> %s %sAhead by %d commit(s)%s", folder, currentBranch, prompt.Color(prompt.FgYellow), record.Ahead, extraInfo)
+	case record.Sync == SYNC_BEHIND:
+		p.PrintInfo("'%s' -> %s %sBehind by %d commit(s)%s", folder, currentBranch, prompt.Color(prompt.FgYellow), record.Behind, extraInfo)
+	case record.Sync == SYNC_DIVERGED:
+		p.PrintInfo("'%s' -> %s %sDiverged (%d ahead, %d behind)%s", folder, currentBranch, prompt.Color(prompt.FgRed), record.Ahead, record.Behind, extraInfo)
+	default:
+		p.PrintInfo("'%s' -> %s %sClean%s", folder, currentBranch, prompt.Color(prompt.FgGreen), extraInfo)
 	}
+
+	return nil
 }
 
-// Compute Clone URL based on selected protocol
+// Compute Clone URL based on protocol selected for repository remote
 func (g *GitCommands) computeCloneUrl(repo gitRepository) string {
 	cloneProtocol := g.config.Git.CloneProtocol
+	if r, err := g.repoRemote(repo); err == nil {
+		cloneProtocol = r.config.Git.CloneProtocol
+	}
 	switch cloneProtocol {
 	case "ssh":
 		return repo.SshUrl
@@ -599,24 +1025,34 @@ func (g *GitCommands) computeCloneUrl(repo gitRepository) string {
 	}
 }
 
-// Compute authorization GIT parameter if required
-func (g *GitCommands) authorization() []string {
+// Compute authorization GIT parameter of remote if required, token is provided by mbx as credential helper
+func (g *GitCommands) authorization(r *remote) []string {
 
-	cloneProtocol := g.config.Git.CloneProtocol
-	usePatToken := g.config.Git.UseTokenForOperation
+	cloneProtocol := r.config.Git.CloneProtocol
+	usePatToken := r.config.Git.UseTokenForOperation
 
 	if cloneProtocol != "https" || !usePatToken {
 		return []string{}
 	}
 
-	pat := base64.StdEncoding.EncodeToString([]byte(":" + g.config.Git.PrivateToken))
-	return []string{"-c", "http.extraHeader=Authorization: Basic " + pat}
+	return credentialHelperArgs()
 }
 
-// Return all git folders matching with glob
-func getGitFolders(globPattern string, exclusion []string) (folders []string, err error) {
+// Return all git folders matching with glob, using workspace layout depth
+func (g *GitCommands) getGitFolders(globPattern string, exclusion []string) (folders []string, err error) {
 
-	dir, err := os.Getwd()
+	maxDepth := MAX_DEPTH
+	if g.config.Git.Layout == LAYOUT_NAMESPACE {
+		maxDepth = MAX_NAMESPACE_DEPTH
+	}
+
+	return getGitFolders(globPattern, exclusion, maxDepth)
+}
+
+// Return all git folders matching with glob, up to max depth
+func getGitFolders(globPattern string, exclusion []string, maxDepth int) (folders []string, err error) {
+
+	dir, err := config.WorkspaceDir()
 	if err != nil {
 		return nil, err
 	}
@@ -626,24 +1062,26 @@ func getGitFolders(globPattern string, exclusion []string) (folders []string, er
 	var gitFolders []string
 
 	walkErr := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if info.IsDir() {
+		if err != nil || !info.IsDir() || path == dir {
+			return nil
+		}
 
-			relativePath := strings.TrimPrefix(filepath.Clean(strings.TrimPrefix(path, dir)), string(os.PathSeparator))
+		relativePath := strings.TrimPrefix(filepath.Clean(strings.TrimPrefix(path, dir)), string(os.PathSeparator))
 
-			if strings.HasSuffix(relativePath, "/.git") {
-				folder := strings.TrimSuffix(relativePath, "/.git")
-				match, _ := matcher.Match(folder)
-				if match {
-					gitFolders = append(gitFolders, folder)
-				}
-			}
+		if strings.HasPrefix(filepath.Base(relativePath), ".") || strings.Count(relativePath, string(os.PathSeparator)) >= maxDepth {
+			return filepath.SkipDir
+		}
 
-			if strings.Count(relativePath, string(os.PathSeparator)) >= MAX_DEPTH {
-				return filepath.SkipDir
+		if _, statErr := os.Stat(filepath.Join(path, ".git")); statErr == nil {
+			match, _ := matcher.Match(filepath.ToSlash(relativePath))
+			if match {
+				gitFolders = append(gitFolders, filepath.ToSlash(relativePath))
 			}
 
-			return nil
+			// Don't walk inside working tree of repository
+			return filepath.SkipDir
 		}
+
 		return nil
 	})
 
@@ -656,6 +1094,47 @@ func getGitFolders(globPattern string, exclusion []string) (folders []string, er
 	return gitFolders, nil
 }
 
+// Return glob to use when none is given, the repository ( or folder ) containing current dir
+func (g *GitCommands) defaultGlob(globPattern string) string {
+
+	if globPattern != "" {
+		return globPattern
+	}
+
+	workspaceDir, err := config.WorkspaceDir()
+	if err != nil {
+		return ""
+	}
+
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+
+	relativePath, err := filepath.Rel(workspaceDir, currentDir)
+	if err != nil || relativePath == "." || strings.HasPrefix(relativePath, "..") {
+		return ""
+	}
+
+	// Search repository containing current dir
+	for dir := relativePath; dir != "."; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(workspaceDir, dir, ".git")); err == nil {
+			return filepath.ToSlash(dir)
+		}
+	}
+
+	// Inside a folder of repositories ( namespace layout )
+	return filepath.ToSlash(relativePath) + "/*"
+}
+
+// Return local folder of repository, depending on workspace layout
+func (g *GitCommands) localPath(repo gitRepository) string {
+	if g.config.Git.Layout == LAYOUT_NAMESPACE {
+		return repo.PathWithNamespace
+	}
+	return repo.Path
+}
+
 // Return remote origin URL
 func getRepoUrl(folder string) string {
 	out, err := cmd.ExecCmd("git", []string{"-C", file.Rel(folder), "remote", "get-url", "origin"})
@@ -666,9 +1145,34 @@ func getRepoUrl(folder string) string {
 	return strings.TrimSpace(out)
 }
 
+// Return default branch of repository, current branch for repositories only known locally
+func (g *GitCommands) repoDefaultBranch(folder string, repo gitRepository) string {
+
+	if defaultBranch := g.remoteDefaultBranch(repo); repo.Id != "" && defaultBranch != "" {
+		return defaultBranch
+	}
+	return getBranch(folder)
+}
+
+// Return default branch of remote repository, resolved on demand when not returned by listing ( ex: Bitbucket )
+func (g *GitCommands) remoteDefaultBranch(repo gitRepository) string {
+
+	if repo.Id != "" && repo.DefaultBranch == "" {
+		if r, err := g.repoRemote(repo); err == nil {
+			if resolver, ok := r.impl.(defaultBranchResolver); ok {
+				return resolver.getDefaultBranch(&repo)
+			}
+		}
+	}
+	return repo.DefaultBranch
+}
+
 // Return current branch name
 func getBranch(folder string) string {
-	out, _ := cmd.ExecCmd("git", []string{"-C", file.Rel(folder), "rev-parse", "--abbrev-ref", "HEAD"})
+	out, err := cmd.ExecCmd("git", []string{"-C", file.Rel(folder), "rev-parse", "--abbrev-ref", "HEAD"})
+	if err != nil {
+		return ""
+	}
 	return strings.Trim(out, "\n ")
 }
 
@@ -678,6 +1182,13 @@ func checkUncachedUncommitted(folder string) bool {
 	return err == nil
 }
 
+// Return true if tracked files are changed, staged or not, as committed by exec ( untracked files are ignored )
+// Shared by exec and its dry-run, path is the repository or worktree path
+func hasTrackedChanges(path string) bool {
+	_, err := cmd.ExecCmd("git", []string{"-C", path, "diff", "HEAD", "--quiet"})
+	return err != nil
+}
+
 // Return true if files is changed and cached in GIT repo
 func checkCachedUncommitted(folder string) bool {
 	_, err := cmd.ExecCmd("git", []string{"-C", file.Rel(folder), "diff", "--cached", "--exit-code"})
@@ -707,13 +1218,29 @@ func checkBranchOrigin(folder string, branch string) (string, string) {
 }
 
 // Return true if files is changed and not tracked in GIT repo
-func (g *GitCommands) clone(cloneUrl string, folder string) (string, error) {
-	return cmd.ExecCmd("git", append(g.authorization(), []string{"clone", "-q", cloneUrl, folder}...))
+func (g *GitCommands) clone(r *remote, cloneUrl string, folder string) (string, error) {
+
+	authorization := g.authorization(r)
+
+	out, err := cmd.ExecCmd("git", append(authorization, []string{"clone", "-q", cloneUrl, file.Rel(folder)}...))
+	if err != nil || len(authorization) == 0 {
+		return out, err
+	}
+
+	// Keep credential helper in repository, so plain GIT commands are authenticated too
+	cmd.ExecCmd("git", []string{"-C", file.Rel(folder), "config", "--unset-all", "credential.helper"}) //nolint:errcheck
+	for _, entry := range persistedCredentialHelperConfig() {
+		if _, err := cmd.ExecCmd("git", []string{"-C", file.Rel(folder), "config", "--add", entry[0], entry[1]}); err != nil {
+			return out, err
+		}
+	}
+
+	return out, nil
 }
 
 // Return true if files is changed and not tracked in GIT repo
 func (g *GitCommands) fetch(folder string) {
-	cmd.ExecCmd("git", append(g.authorization(), []string{"-C", file.Rel(folder), "fetch"}...)) //nolint:errcheck
+	cmd.ExecCmd("git", append(g.authorization(g.folderRemote(folder)), []string{"-C", file.Rel(folder), "fetch"}...)) //nolint:errcheck
 }
 
 // Checkout existing branch
@@ -728,9 +1255,9 @@ func branch(folder string, branch string) {
 
 // Cleanup repo and update to specified branch
 func (g *GitCommands) cleanup(folder string, branch string) {
-	cmd.ExecCmd("git", []string{"-C", file.Rel(folder), "stash", "save", "Auto-stash by microcli"})              //nolint:errcheck
-	cmd.ExecCmd("git", []string{"-C", file.Rel(folder), "checkout", branch})                                     //nolint:errcheck
-	cmd.ExecCmd("git", append(g.authorization(), []string{"-C", file.Rel(folder), "pull", "-q", "--rebase"}...)) //nolint:errcheck
+	cmd.ExecCmd("git", []string{"-C", file.Rel(folder), "stash", "save", "Auto-stash by microcli"})                                    //nolint:errcheck
+	cmd.ExecCmd("git", []string{"-C", file.Rel(folder), "checkout", branch})                                                           //nolint:errcheck
+	cmd.ExecCmd("git", append(g.authorization(g.folderRemote(folder)), []string{"-C", file.Rel(folder), "pull", "-q", "--rebase"}...)) //nolint:errcheck
 }
 
 // Show diff
@@ -738,8 +1265,8 @@ func displayDiff(folder string) error {
 	return cmd.ExecAndOutCmd("git", []string{"-C", file.Rel(folder), "diff"})
 }
 
-// Return true if files is changed and not tracked in GIT repo
-func addAndCommit(folder string, comment string, onlyTracked bool) {
+// Add changed files and commit them
+func addAndCommit(folder string, comment string, onlyTracked bool) error {
 
 	params := []string{"-C", file.Rel(folder), "add"}
 	if onlyTracked {
@@ -748,20 +1275,21 @@ func addAndCommit(folder string, comment string, onlyTracked bool) {
 
 	out, err := cmd.ExecCmd("git", append(params, "."))
 	if err != nil {
-		prompt.PrintError(out)
-		return
+		return errors.New(cmd.ErrorString(out))
 	}
 
 	out, err = cmd.ExecCmd("git", []string{"-C", file.Rel(folder), "commit", "-m", comment})
 	if err != nil {
-		prompt.PrintError(out)
+		return errors.New(cmd.ErrorString(out))
 	}
+
+	return nil
 }
 
-// Return true if files is changed and not tracked in GIT repo
-func (g *GitCommands) push(folder string, track string) {
+// Push current branch, tracking remote branch if specified
+func (g *GitCommands) push(folder string, track string) error {
 
-	params := g.authorization()
+	params := g.authorization(g.folderRemote(folder))
 	params = append(params, "-C", file.Rel(folder), "push")
 
 	if len(track) > 0 {
@@ -770,8 +1298,10 @@ func (g *GitCommands) push(folder string, track string) {
 
 	out, err := cmd.ExecCmd("git", params)
 	if err != nil {
-		prompt.PrintError(out)
+		return errors.New(cmd.ErrorString(out))
 	}
+
+	return nil
 }
 
 // Check out string match with empty cloned repository
@@ -0,0 +1,61 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vanroy/microcli/impl/config"
+)
+
+func TestDefaultGlob(t *testing.T) {
+
+	workspace := t.TempDir()
+	for _, dir := range []string{".microbox", "team/api/.git", "team/api/src"} {
+		if err := os.MkdirAll(filepath.Join(workspace, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(filepath.Join(workspace, config.CONFIG_FILE), nil, 0644) //nolint:errcheck
+
+	tests := []struct {
+		dir      string
+		pattern  string
+		expected string
+	}{
+		{"", "", ""},
+		{"", "team/*", "team/*"},
+		{"team", "", "team/*"},
+		{"team/api", "", "team/api"},
+		{"team/api/src", "", "team/api"},
+	}
+
+	g := &GitCommands{}
+	for _, test := range tests {
+		t.Chdir(filepath.Join(workspace, test.dir))
+		if glob := g.defaultGlob(test.pattern); glob != test.expected {
+			t.Errorf("'%s' from '%s' : unexpected glob '%s'", test.pattern, test.dir, glob)
+		}
+	}
+}
+
+func TestInitInsideWorkspace(t *testing.T) {
+
+	workspace := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(workspace, ".microbox"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(workspace, config.CONFIG_FILE), []byte("[Git]\n"), 0644) //nolint:errcheck
+	os.Mkdir(filepath.Join(workspace, "team"), 0755)                                    //nolint:errcheck
+
+	// Config of parent workspace must be kept
+	t.Chdir(filepath.Join(workspace, "team"))
+	if err := Init(context.Background(), nil); err == nil {
+		t.Errorf("expected init to be refused inside workspace")
+	}
+
+	if content, _ := os.ReadFile(filepath.Join(workspace, config.CONFIG_FILE)); string(content) != "[Git]\n" {
+		t.Errorf("unexpected workspace config %q", content)
+	}
+}
@@ -0,0 +1,115 @@
+package git
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/vanroy/microcli/impl/config"
+	"github.com/vanroy/microcli/impl/secret"
+)
+
+const (
+	CREDENTIAL_COMMAND  = "credential"
+	CREDENTIAL_USERNAME = "microbox"
+)
+
+// Act as GIT credential helper ( get / store / erase ), only get is supported as tokens are managed by mbx
+// Token of remote matching with requested host is written on out, nothing if no remote match
+func CredentialHelper(action string, in io.Reader, out io.Writer) error {
+
+	// Started by git-credential secret store of microbox, token would be retrieved recursively
+	if action != "get" || os.Getenv(secret.GIT_CREDENTIAL_ENV) != "" {
+		return nil
+	}
+
+	request := map[string]string{}
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+		if key, value, found := strings.Cut(line, "="); found {
+			request[key] = value
+		}
+	}
+
+	// Only token of matching remote is retrieved, other secret stores are never queried
+	conf, err := config.LoadSettings()
+	if conf == nil {
+		if err == nil {
+			err = errors.New("workspace is not initialized")
+		}
+		return err
+	}
+
+	host := urlHost(request["protocol"] + "://" + request["host"])
+
+	for _, name := range conf.RemoteNames() {
+		remoteConf := conf.ForRemote(name).Git
+		if urlHost(remoteConf.BaseUrl) != host || !remoteConf.UseTokenForOperation {
+			continue
+		}
+
+		username := request["username"]
+		if username == "" {
+			username = CREDENTIAL_USERNAME
+		}
+
+		// Token is provided to GIT by its own credential helper with git-credential secret store
+		if remoteConf.SecretStore == secret.GIT_CREDENTIAL {
+			return nil
+		}
+
+		if err := config.ApplySecret(&remoteConf); err != nil {
+			return err
+		}
+		token := remoteConf.PrivateToken
+
+		// GitHub App tokens are scoped to an installation, owner is the first segment of repository path
+		if remoteConf.AuthMode == GITHUB_APP_AUTH_MODE {
+			owner, _, _ := strings.Cut(request["path"], "/")
+			installationToken, err := newGitHub(conf.ForRemote(name)).(*gitHub).installationToken(owner)
+			if err != nil {
+				return err
+			}
+			token = installationToken
+			username = GITHUB_APP_USERNAME
+		}
+
+		if token == "" {
+			return fmt.Errorf("no token available for remote '%s'", name)
+		}
+
+		_, err := fmt.Fprintf(out, "username=%s\npassword=%s\n", username, token)
+		return err
+	}
+
+	return nil
+}
+
+// Return GIT config arguments using mbx as only credential helper
+func credentialHelperArgs() []string {
+
+	executable, err := os.Executable()
+	if err != nil {
+		return []string{}
+	}
+
+	// Empty value reset helpers defined in user / system config, path is required to select GitHub App installation
+	return []string{"-c", "credential.helper=", "-c", "credential.helper=" + credentialHelperCommand(executable), "-c", "credential.useHttpPath=true"}
+}
+
+// Return GIT config kept in cloned repositories, mbx is resolved from PATH as executable can be moved or upgraded
+func persistedCredentialHelperConfig() [][]string {
+	return [][]string{{"credential.helper", "!mbx " + CREDENTIAL_COMMAND}, {"credential.useHttpPath", "true"}}
+}
+
+// Return credential helper command of executable, as shell command
+func credentialHelperCommand(executable string) string {
+	return "!'" + strings.ReplaceAll(executable, "'", `'\''`) + "' " + CREDENTIAL_COMMAND
+}
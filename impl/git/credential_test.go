@@ -0,0 +1,70 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vanroy/microcli/impl/config"
+	"github.com/vanroy/microcli/impl/secret"
+)
+
+func TestCredentialHelper(t *testing.T) {
+
+	workspace := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(workspace, ".microbox"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	err := os.WriteFile(filepath.Join(workspace, config.CONFIG_FILE), []byte(`
+[Git]
+Type = "gitlab"
+BaseUrl = "https://gitlab.example.com"
+CloneProtocol = "https"
+UseTokenForOperation = true
+
+[Remotes.oss]
+Type = "github"
+BaseUrl = "https://github.example.com"
+SecretStore = "command"
+SecretCommand = "exit 1"
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("MBX_GIT_PRIVATE_TOKEN", "secret")
+	t.Chdir(workspace)
+
+	var out strings.Builder
+	if err := CredentialHelper("get", strings.NewReader("protocol=https\nhost=gitlab.example.com\n\n"), &out); err != nil {
+		t.Fatalf("Cannot get credential : %s", err.Error())
+	}
+	if out.String() != "username=microbox\npassword=secret\n" {
+		t.Errorf("Unexpected credential %q", out.String())
+	}
+
+	out.Reset()
+	if err := CredentialHelper("get", strings.NewReader("protocol=https\nhost=github.com\n\n"), &out); err != nil {
+		t.Fatalf("Cannot get credential : %s", err.Error())
+	}
+	if out.String() != "" {
+		t.Errorf("No credential expected for unknown host, got %q", out.String())
+	}
+
+	// Started from git-credential secret store, helper must not query secret stores again
+	t.Setenv(secret.GIT_CREDENTIAL_ENV, "1")
+	out.Reset()
+	if err := CredentialHelper("get", strings.NewReader("protocol=https\nhost=gitlab.example.com\n\n"), &out); err != nil || out.String() != "" {
+		t.Errorf("No credential expected from git-credential secret store, got %q", out.String())
+	}
+}
+
+func TestValidateRemoteGitCredentialStore(t *testing.T) {
+
+	conf := config.GitConfig{Type: "gitlab", BaseUrl: "https://gitlab.example.com", SecretStore: secret.GIT_CREDENTIAL, UseTokenForOperation: true}
+	if problems := validateRemote("Git.", conf, false); len(problems) != 1 || !strings.HasPrefix(problems[0], "Git.UseTokenForOperation") {
+		t.Errorf("Unexpected problems %v", problems)
+	}
+}
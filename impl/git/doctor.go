@@ -0,0 +1,322 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/thoas/go-funk"
+	"github.com/urfave/cli/v3"
+	"github.com/vanroy/microcli/impl/action"
+	"github.com/vanroy/microcli/impl/cmd"
+	"github.com/vanroy/microcli/impl/config"
+	"github.com/vanroy/microcli/impl/output"
+	"github.com/vanroy/microcli/impl/prompt"
+	"github.com/vanroy/microcli/impl/secret"
+)
+
+const (
+	DOCTOR_PASS = "pass"
+	DOCTOR_WARN = "warn"
+	DOCTOR_FAIL = "fail"
+
+	// Required by credential helper reset ( -c credential.helper= ) and pull --autostash
+	MIN_GIT_VERSION = "2.9"
+)
+
+type doctorCheck struct {
+	Name    string `json:"name" yaml:"name"`
+	Status  string `json:"status" yaml:"status"`
+	Message string `json:"message" yaml:"message"`
+	Fix     string `json:"fix,omitempty" yaml:"fix,omitempty"`
+}
+
+// Check workspace dependencies ( tools, config, remotes, actions ) and print report with fixes
+func (g *GitCommands) Doctor(_ context.Context, c *cli.Command) error {
+
+	checks := []doctorCheck{checkGitVersion(), checkConfig(g.config)}
+
+	if check, ok := checkKeychain(g.remotes); ok {
+		checks = append(checks, check)
+	}
+
+	for _, r := range g.remotes {
+		checks = append(checks, checkRemote(r)...)
+	}
+
+	checks = append(checks, checkInitializr(g.initializr.Url()), checkActions())
+
+	return printDoctorChecks(checks)
+}
+
+// Return doctor action used when config cannot be loaded, load error is reported as failed config check
+func DoctorConfigError(configErr error) cli.ActionFunc {
+
+	return func(_ context.Context, c *cli.Command) error {
+		return printDoctorChecks([]doctorCheck{
+			checkGitVersion(),
+			{Name: "Config", Status: DOCTOR_FAIL, Message: configErr.Error(), Fix: "Fix or remove invalid settings in '" + config.File() + "'"},
+			checkActions(),
+		})
+	}
+}
+
+// Print report of checks with fixes, an error is returned if a check failed
+func printDoctorChecks(checks []doctorCheck) error {
+
+	if output.IsStructured() {
+		return output.Print(checks)
+	}
+
+	for _, check := range checks {
+		switch check.Status {
+		case DOCTOR_PASS:
+			prompt.PrintInfo("%s : %s%s", check.Name, prompt.Color(prompt.FgGreen), check.Message)
+		case DOCTOR_WARN:
+			prompt.PrintWarn("%s : %s", check.Name, check.Message)
+		default:
+			prompt.PrintErrorf("%s : %s", check.Name, check.Message)
+		}
+		if check.Fix != "" {
+			prompt.PrintItem("Fix : " + check.Fix)
+		}
+	}
+
+	warnings := funk.Filter(checks, func(check doctorCheck) bool { return check.Status == DOCTOR_WARN }).([]doctorCheck)
+	failures := funk.Filter(checks, func(check doctorCheck) bool { return check.Status == DOCTOR_FAIL }).([]doctorCheck)
+
+	prompt.PrintNewLine()
+	prompt.PrintInfo("%d %spassed%s, %d %swarnings%s, %d %sfailed", len(checks)-len(warnings)-len(failures), prompt.Color(prompt.FgGreen), prompt.Color(prompt.FgWhite), len(warnings), prompt.Color(prompt.FgYellow), prompt.Color(prompt.FgWhite), len(failures), prompt.Color(prompt.FgRed))
+
+	if len(failures) > 0 {
+		return errors.New("workspace is not healthy")
+	}
+
+	return nil
+}
+
+// Check GIT is installed with a supported version
+func checkGitVersion() doctorCheck {
+
+	check := doctorCheck{Name: "GIT"}
+
+	out, err := cmd.ExecCmd("git", []string{"--version"})
+	if err != nil {
+		check.Status, check.Message, check.Fix = DOCTOR_FAIL, "git is not installed", "Install git "+MIN_GIT_VERSION+" or later"
+		return check
+	}
+
+	version := regexp.MustCompile(`\d+(\.\d+)+`).FindString(out)
+	if compareVersions(version, MIN_GIT_VERSION) < 0 {
+		check.Status, check.Message, check.Fix = DOCTOR_FAIL, "git "+version+" is not supported", "Upgrade git to "+MIN_GIT_VERSION+" or later"
+		return check
+	}
+
+	check.Status, check.Message = DOCTOR_PASS, "git "+version
+	return check
+}
+
+// Check effective config, network checks are done per remote
+func checkConfig(conf config.Config) doctorCheck {
+
+	problems := validateConfig(conf, false)
+	if len(problems) > 0 {
+		return doctorCheck{Name: "Config", Status: DOCTOR_FAIL, Message: strings.Join(problems, ", "), Fix: "Fix settings with 'mbx config set' or 'mbx config edit'"}
+	}
+
+	return doctorCheck{Name: "Config", Status: DOCTOR_PASS, Message: "valid"}
+}
+
+// Check OS keychain can be used, only if a remote store its token in it
+func checkKeychain(remotes []*remote) (doctorCheck, bool) {
+
+	var names []string
+	for _, r := range remotes {
+		conf := r.config.Git
+		if (conf.AuthMode == "pat" || conf.AuthMode == OAUTH_AUTH_MODE) && (conf.SecretStore == "" || conf.SecretStore == secret.KEYRING) {
+			names = append(names, r.name)
+		}
+	}
+
+	if len(names) == 0 {
+		return doctorCheck{}, false
+	}
+
+	if err := secret.CheckKeyring(); err != nil {
+		return doctorCheck{
+			Name:    "Keychain",
+			Status:  DOCTOR_FAIL,
+			Message: fmt.Sprintf("OS keychain is not available ( %s )", err.Error()),
+			Fix:     fmt.Sprintf("Use another secret store ( ex: 'mbx config set %sSecretStore %s' ) then 'mbx authenticate'", config.RemoteKeyPrefix(names[0]), secret.FILE),
+		}, true
+	}
+
+	return doctorCheck{Name: "Keychain", Status: DOCTOR_PASS, Message: "available"}, true
+}
+
+// Check remote API is reachable and token is accepted, and tools used by remote ( SSH, Azure CLI )
+func checkRemote(r *remote) []doctorCheck {
+
+	conf := r.config.Git
+	name := fmt.Sprintf("Remote '%s'", r.name)
+	var checks []doctorCheck
+
+	if conf.AuthMode == "az-cli" {
+		checks = append(checks, checkAzureCli(name))
+	}
+
+	if err := checkReachable(conf.Type, conf.BaseUrl); err != nil {
+		checks = append(checks, doctorCheck{
+			Name:    name,
+			Status:  DOCTOR_FAIL,
+			Message: fmt.Sprintf("%s is not reachable ( %s )", conf.BaseUrl, err.Error()),
+			Fix:     fmt.Sprintf("Check network / proxy, or fix URL with 'mbx config set %sBaseUrl <url>'", config.RemoteKeyPrefix(r.name)),
+		})
+		return checks
+	}
+
+	info, err := r.impl.getTokenInfo()
+	switch {
+	case err != nil:
+		checks = append(checks, doctorCheck{
+			Name:    name,
+			Status:  DOCTOR_FAIL,
+			Message: fmt.Sprintf("authentication failed ( %s )", err.Error()),
+			Fix:     fmt.Sprintf("Check %sSecretStore, or renew token with 'mbx authenticate --remote %s'", config.RemoteKeyPrefix(r.name), r.name),
+		})
+	case len(info.Missing) > 0:
+		missing := funk.Map(info.Missing, func(requirement scopeRequirement) string {
+			return strings.Join(requirement.Scopes, " or ") + " ( " + requirement.Usage + " )"
+		}).([]string)
+		checks = append(checks, doctorCheck{
+			Name:    name,
+			Status:  DOCTOR_WARN,
+			Message: "missing scopes " + strings.Join(missing, ", "),
+			Fix:     fmt.Sprintf("Create a token with these scopes, then 'mbx authenticate --remote %s'", r.name),
+		})
+	case info.User != "":
+		checks = append(checks, doctorCheck{Name: name, Status: DOCTOR_PASS, Message: "authenticated as " + info.User})
+	default:
+		checks = append(checks, doctorCheck{Name: name, Status: DOCTOR_PASS, Message: "reachable"})
+	}
+
+	if conf.CloneProtocol == "ssh" && conf.Type != "local" {
+		checks = append(checks, checkSsh(name, sshHost(conf), config.RemoteKeyPrefix(r.name)))
+	}
+
+	return checks
+}
+
+// Check Azure CLI is installed and logged in
+func checkAzureCli(name string) doctorCheck {
+
+	if _, err := exec.LookPath("az"); err != nil {
+		return doctorCheck{Name: name, Status: DOCTOR_FAIL, Message: "Azure CLI is not installed", Fix: "Install Azure CLI ( https://learn.microsoft.com/cli/azure/install-azure-cli )"}
+	}
+
+	if _, err := cmd.ExecCmd("az", []string{"account", "show"}); err != nil {
+		return doctorCheck{Name: name, Status: DOCTOR_FAIL, Message: "Azure CLI is not logged in", Fix: "Run 'az login'"}
+	}
+
+	return doctorCheck{Name: name, Status: DOCTOR_PASS, Message: "Azure CLI logged in"}
+}
+
+// Check SSH agent provide keys and host key is known, without connecting to host
+func checkSsh(name string, host string, keyPrefix string) doctorCheck {
+
+	if _, err := exec.LookPath("ssh"); err != nil {
+		return doctorCheck{Name: name, Status: DOCTOR_FAIL, Message: "ssh is not installed", Fix: "Install OpenSSH client, or use HTTPS with 'mbx config set " + keyPrefix + "CloneProtocol https'"}
+	}
+
+	if _, err := cmd.ExecCmd("ssh-keygen", []string{"-F", host}); err != nil {
+		return doctorCheck{Name: name, Status: DOCTOR_WARN, Message: fmt.Sprintf("host key of %s is not known", host), Fix: fmt.Sprintf("Verify and add host key with 'ssh-keyscan %s >> ~/.ssh/known_hosts'", host)}
+	}
+
+	// Keys configured with IdentityFile can be used without agent
+	if os.Getenv("SSH_AUTH_SOCK") == "" {
+		return doctorCheck{Name: name, Status: DOCTOR_WARN, Message: "SSH agent is not running", Fix: "Start agent with 'eval $(ssh-agent)' and add your key with 'ssh-add'"}
+	}
+	if _, err := cmd.ExecCmd("ssh-add", []string{"-l"}); err != nil {
+		return doctorCheck{Name: name, Status: DOCTOR_WARN, Message: "no key loaded in SSH agent", Fix: "Add your key with 'ssh-add'"}
+	}
+
+	return doctorCheck{Name: name, Status: DOCTOR_PASS, Message: fmt.Sprintf("SSH key available for %s", host)}
+}
+
+// Check Initializr respond, it is only required by gadd --init and ginit
+func checkInitializr(url string) doctorCheck {
+
+	if _, err := resty.New().SetTimeout(REACHABILITY_TIMEOUT).R().Get(url); err != nil {
+		return doctorCheck{Name: "Initializr", Status: DOCTOR_WARN, Message: fmt.Sprintf("%s is not reachable ( %s )", url, err.Error()), Fix: "Check network / proxy, or fix URL with 'mbx config set Initializr.Url <url>'"}
+	}
+
+	return doctorCheck{Name: "Initializr", Status: DOCTOR_PASS, Message: url + " reachable"}
+}
+
+// Check action scripts of workspace are executable
+func checkActions() doctorCheck {
+
+	dir, _ := config.WorkspaceDir()
+	if _, err := os.Stat(action.Dir(dir)); err != nil {
+		return doctorCheck{Name: "Actions", Status: DOCTOR_PASS, Message: "no action defined"}
+	}
+
+	actions, err := action.List(action.Dir(dir))
+	if err != nil {
+		return doctorCheck{Name: "Actions", Status: DOCTOR_WARN, Message: err.Error(), Fix: "Fix manifest of action, then check it with 'mbx exec --list'"}
+	}
+
+	var notExecutable []string
+	for _, act := range actions {
+		if info, err := os.Stat(act.Path); err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+			path, _ := filepath.Rel(dir, act.Path)
+			notExecutable = append(notExecutable, path)
+		}
+	}
+
+	if len(notExecutable) > 0 {
+		return doctorCheck{Name: "Actions", Status: DOCTOR_WARN, Message: "not executable " + strings.Join(notExecutable, ", "), Fix: "Run 'chmod +x " + strings.Join(notExecutable, " ") + "'"}
+	}
+
+	return doctorCheck{Name: "Actions", Status: DOCTOR_PASS, Message: fmt.Sprintf("%d actions executable", len(actions))}
+}
+
+// Return SSH host of remote, Azure DevOps serve SSH on a dedicated host
+func sshHost(conf config.GitConfig) string {
+
+	host := urlHost(conf.BaseUrl)
+	if conf.Type == "azure" {
+		return "ssh." + host
+	}
+
+	return host
+}
+
+// Compare dotted versions, return negative if a < b, 0 if equals, positive if a > b
+func compareVersions(a string, b string) int {
+
+	partsA := strings.Split(a, ".")
+	partsB := strings.Split(b, ".")
+
+	for i := 0; i < len(partsA) || i < len(partsB); i++ {
+		var numA, numB int
+		if i < len(partsA) {
+			numA, _ = strconv.Atoi(partsA[i])
+		}
+		if i < len(partsB) {
+			numB, _ = strconv.Atoi(partsB[i])
+		}
+		if numA != numB {
+			return numA - numB
+		}
+	}
+
+	return 0
+}
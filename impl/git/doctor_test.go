@@ -0,0 +1,50 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckActions(t *testing.T) {
+
+	workspace := t.TempDir()
+	t.Chdir(workspace)
+
+	actionsDir := filepath.Join(workspace, ".microbox", "actions")
+	if err := os.MkdirAll(actionsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(actionsDir, "bump"), []byte("#!/bin/sh\n"), 0755)   //nolint:errcheck
+	os.WriteFile(filepath.Join(actionsDir, "format"), []byte("#!/bin/sh\n"), 0644) //nolint:errcheck
+	os.WriteFile(filepath.Join(actionsDir, "format.toml"), nil, 0644)              //nolint:errcheck
+
+	check := checkActions()
+	if check.Status != DOCTOR_WARN || check.Fix != "Run 'chmod +x "+filepath.Join(".microbox", "actions", "format")+"'" {
+		t.Errorf("expected warning for not executable action, got %+v", check)
+	}
+
+	os.Chmod(filepath.Join(actionsDir, "format"), 0755) //nolint:errcheck
+
+	if check := checkActions(); check.Status != DOCTOR_PASS {
+		t.Errorf("expected actions to pass, got %+v", check)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+
+	if compareVersions("2.39.5", MIN_GIT_VERSION) <= 0 || compareVersions("2.8.1", MIN_GIT_VERSION) >= 0 || compareVersions("2.9", "2.9.0") != 0 {
+		t.Errorf("unexpected version comparison")
+	}
+}
+
+func TestDoctorConfigError(t *testing.T) {
+
+	t.Chdir(t.TempDir())
+
+	if err := DoctorConfigError(errors.New("cannot read config"))(context.Background(), nil); err == nil {
+		t.Errorf("expected config error to fail doctor")
+	}
+}
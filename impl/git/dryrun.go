@@ -0,0 +1,131 @@
+package git
+
+import (
+	"os"
+	"strings"
+
+	"github.com/thoas/go-funk"
+	"github.com/vanroy/microcli/impl/action"
+	"github.com/vanroy/microcli/impl/cmd"
+	"github.com/vanroy/microcli/impl/file"
+	"github.com/vanroy/microcli/impl/output"
+	"github.com/vanroy/microcli/impl/prompt"
+)
+
+// Result of action executed in dry-run mode, with the steps that would be done
+type dryRunRecord struct {
+	Name     string `json:"name" yaml:"name"`
+	Changed  bool   `json:"changed" yaml:"changed"`
+	DiffStat string `json:"diffStat,omitempty" yaml:"diffStat,omitempty"`
+	Diff     string `json:"diff,omitempty" yaml:"diff,omitempty"`
+	Branch   string `json:"branch,omitempty" yaml:"branch,omitempty"`
+	Commit   string `json:"commit,omitempty" yaml:"commit,omitempty"`
+	Review   string `json:"review,omitempty" yaml:"review,omitempty"`
+	Error    string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Execute action in a throwaway worktree of default branch, repository is left untouched and nothing is pushed
+func (g *GitCommands) dryRun(folder string, defaultBranch string, act *action.Action, params []string, record dryRunRecord) dryRunRecord {
+
+	worktree, err := os.MkdirTemp("", "mbx-dry-run-")
+	if err != nil {
+		record.Error = err.Error()
+		return record
+	}
+	defer os.RemoveAll(worktree) //nolint:errcheck
+
+	// Start from remote state, as a real execution pull default branch first
+	g.fetch(folder)
+	base := "origin/" + defaultBranch
+	if _, err := cmd.ExecCmd("git", []string{"-C", file.Rel(folder), "rev-parse", "--verify", "-q", base}); err != nil {
+		base = defaultBranch
+	}
+
+	if out, err := cmd.ExecCmd("git", []string{"-C", file.Rel(folder), "worktree", "add", "-q", "--detach", worktree, base}); err != nil {
+		record.Error = cmd.ErrorString(out)
+		return record
+	}
+	defer cmd.ExecCmd("git", []string{"-C", file.Rel(folder), "worktree", "remove", "--force", worktree}) //nolint:errcheck
+
+	if out, err := cmd.ExecCmdDir(act.Path, params, worktree); err != nil {
+		record.Error = err.Error() + " " + cmd.ErrorString(out)
+		return record
+	}
+
+	// Stage as exec commit does, only tracked files ( or staged by action ) are committed
+	record.Changed = hasTrackedChanges(worktree)
+	if record.Changed {
+		cmd.ExecCmd("git", []string{"-C", worktree, "add", "-u", "."}) //nolint:errcheck
+		record.DiffStat, _ = cmd.ExecCmd("git", []string{"-C", worktree, "diff", "--cached", "--stat"})
+		record.Diff, _ = cmd.ExecCmd("git", []string{"-C", worktree, "diff", "--cached"})
+	}
+
+	if !output.IsStructured() {
+		g.printDryRun(worktree, record)
+	}
+
+	if !record.Changed || record.Commit == "" {
+		record.Commit, record.Branch, record.Review = "", "", ""
+	}
+
+	return record
+}
+
+// Print diff and steps that would be done for repository
+func (g *GitCommands) printDryRun(worktree string, record dryRunRecord) {
+
+	if !record.Changed {
+		prompt.PrintInfo("Dry run for '%s' : %snothing to commit", record.Name, prompt.Color(prompt.FgYellow))
+		return
+	}
+
+	prompt.PrintInfo("Dry run for '%s' : %schanges", record.Name, prompt.Color(prompt.FgYellow))
+	prompt.PrintItem(strings.ReplaceAll(record.DiffStat, "\n", "\n* "))
+	cmd.ExecAndOutCmd("git", []string{"--no-pager", "-C", worktree, "diff", "--cached"}) //nolint:errcheck
+
+	if record.Commit == "" {
+		prompt.PrintInfo("Dry run for '%s' : %sno commit message, changes would not be committed", record.Name, prompt.Color(prompt.FgYellow))
+		return
+	}
+
+	prompt.PrintInfo("Dry run for '%s' : would commit '%s'", record.Name, record.Commit)
+	if record.Branch != "" {
+		prompt.PrintInfo("Dry run for '%s' : would push branch '%s'", record.Name, record.Branch)
+	} else {
+		prompt.PrintInfo("Dry run for '%s' : would push current branch", record.Name)
+	}
+	if record.Review != "" {
+		prompt.PrintInfo("Dry run for '%s' : would create review request '%s'", record.Name, record.Review)
+	}
+}
+
+// Print repositories that would get a commit, branch and review request
+func printDryRunSummary(records []dryRunRecord) error {
+
+	if output.IsStructured() {
+		return output.Print(records)
+	}
+
+	committed := funk.Filter(records, func(record dryRunRecord) bool { return record.Commit != "" }).([]dryRunRecord)
+	reviewed := funk.Filter(records, func(record dryRunRecord) bool { return record.Review != "" }).([]dryRunRecord)
+	failed := funk.Filter(records, func(record dryRunRecord) bool { return record.Error != "" }).([]dryRunRecord)
+
+	prompt.PrintNewLine()
+	prompt.PrintInfo("Dry run : %d repositories would be committed and pushed, %d would get a review request, %d would not be committed", len(committed), len(reviewed), len(records)-len(committed)-len(failed))
+	for _, record := range committed {
+		if record.Branch != "" {
+			prompt.PrintItem(record.Name + " ( " + record.Branch + " )")
+		} else {
+			prompt.PrintItem(record.Name)
+		}
+	}
+
+	if len(failed) > 0 {
+		prompt.PrintInfo("%d %sfailed", len(failed), prompt.Color(prompt.FgRed))
+		for _, record := range failed {
+			prompt.PrintItem(record.Name + " ( " + record.Error + " )")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vanroy/microcli/impl/action"
+	"github.com/vanroy/microcli/impl/config"
+	"github.com/vanroy/microcli/impl/output"
+)
+
+func TestDryRunLeavesRepositoryUntouched(t *testing.T) {
+
+	root := newLocalRoot(t, "platform/api")
+
+	workspace := t.TempDir()
+	t.Chdir(workspace)
+
+	actionsDir := action.Dir(workspace)
+	os.MkdirAll(actionsDir, 0755)                                                                                        //nolint:errcheck
+	os.WriteFile(filepath.Join(actionsDir, "readme"), []byte("#!/bin/sh\necho \"$1\" > README\ngit add README\n"), 0755) //nolint:errcheck
+
+	commands := NewCommands(config.Config{Git: config.GitConfig{Type: "local", BaseUrl: root, GroupIds: []string{"platform"}, CloneProtocol: "ssh"}})
+	if _, err := commands.cloneRepos("", nil, false, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	act, err := action.Load(actionsDir, "readme")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config.Options.Output = output.JSON
+	t.Cleanup(func() { config.Options.Output = output.TABLE })
+
+	record := commands.dryRun("api", "main", act, []string{"hello"}, dryRunRecord{Name: "api", Branch: "readme", Commit: "Add README"})
+	if record.Error != "" || !record.Changed || record.Commit != "Add README" || record.Branch != "readme" {
+		t.Errorf("unexpected dry run %+v", record)
+	}
+	if !strings.Contains(record.Diff, "+++ b/README") || !strings.Contains(record.Diff, "+hello") {
+		t.Errorf("unexpected diff %q", record.Diff)
+	}
+
+	if _, err := os.Stat(filepath.Join(workspace, "api", "README")); !os.IsNotExist(err) {
+		t.Errorf("README should not be created in repository")
+	}
+	if branch := getBranch("api"); branch != "main" {
+		t.Errorf("unexpected branch '%s'", branch)
+	}
+
+	record = commands.dryRun("api", "main", act, []string{"hello"}, dryRunRecord{Name: "api"})
+	if !record.Changed || record.Commit != "" || record.Branch != "" {
+		t.Errorf("changes without commit message should not be committed %+v", record)
+	}
+}
+
+func TestDryRunAgreesWithExec(t *testing.T) {
+
+	root := newLocalRoot(t, "platform/api")
+
+	workspace := t.TempDir()
+	t.Chdir(workspace)
+
+	// Action only staging its changes, nothing left in working tree
+	actionsDir := action.Dir(workspace)
+	os.MkdirAll(actionsDir, 0755)                                                                                        //nolint:errcheck
+	os.WriteFile(filepath.Join(actionsDir, "readme"), []byte("#!/bin/sh\necho \"$1\" > README\ngit add README\n"), 0755) //nolint:errcheck
+	os.WriteFile(filepath.Join(actionsDir, "noop"), []byte("#!/bin/sh\ntouch untracked\n"), 0755)                        //nolint:errcheck
+
+	commands := NewCommands(config.Config{Git: config.GitConfig{Type: "local", BaseUrl: root, GroupIds: []string{"platform"}, CloneProtocol: "ssh"}})
+	repos, err := commands.cloneRepos("", nil, false, 1)
+	if err != nil || len(repos) != 1 {
+		t.Fatalf("unexpected repositories %v ( %v )", repos, err)
+	}
+
+	config.Options.Output = output.JSON
+	t.Cleanup(func() { config.Options.Output = output.TABLE })
+
+	for name, step := range map[string]string{"readme": STEP_DONE, "noop": STEP_UNCHANGED} {
+		act, err := action.Load(actionsDir, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		record := commands.dryRun("api", "main", act, []string{"hello"}, dryRunRecord{Name: "api", Commit: "Update"})
+
+		camp := newCampaign(act.Name)
+		camp.Params = []string{"hello"}
+		camp.CommitMessage = "Update"
+		camp.Repositories = []*campaignRepo{{Name: "api", Step: STEP_PENDING}}
+		acceptAll := false
+		commands.execRepo(camp, camp.Repositories[0], repos[0], act, false, &acceptAll)
+
+		if executed := camp.Repositories[0].Step; executed != step || record.Changed != (step == STEP_DONE) {
+			t.Errorf("%s : dry run changed %t, exec step '%s'", name, record.Changed, executed)
+		}
+	}
+}
@@ -1,6 +1,14 @@
 package git
 
-import "github.com/urfave/cli/v3"
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/thoas/go-funk"
+	"github.com/urfave/cli/v3"
+)
 
 type Labels struct {
 	GroupLabel            string
@@ -24,6 +32,10 @@ type gitRepository struct {
 	GroupId           string
 	DefaultBranch     string
 	Archived          bool
+	Remote            string
+	// Key of project and slug of repository, for remotes addressing repositories by them ( Bitbucket )
+	ProjectKey string
+	Slug       string
 }
 
 type gitGroup struct {
@@ -36,12 +48,51 @@ var PERSONAL_GROUP = gitGroup{
 	Name: "Personal",
 }
 
+// Normalized state, mergeability and checks of review requests
+const (
+	REVIEW_OPEN   = "open"
+	REVIEW_DRAFT  = "draft"
+	REVIEW_MERGED = "merged"
+	REVIEW_CLOSED = "closed"
+
+	MERGEABLE_YES      = "mergeable"
+	MERGEABLE_CONFLICT = "conflict"
+	MERGEABLE_UNKNOWN  = "unknown"
+
+	CHECKS_SUCCESS = "success"
+	CHECKS_FAILURE = "failure"
+	CHECKS_PENDING = "pending"
+	CHECKS_NONE    = "none"
+
+	MERGE_STRATEGY_MERGE  = "merge"
+	MERGE_STRATEGY_SQUASH = "squash"
+	MERGE_STRATEGY_REBASE = "rebase"
+)
+
+var MergeStrategies = []string{MERGE_STRATEGY_MERGE, MERGE_STRATEGY_SQUASH, MERGE_STRATEGY_REBASE}
+
 type reviewRequest struct {
 	Id        string
 	State     string
 	Title     string
 	Url       string
 	Mergeable string
+	Checks    string
+	Approvals int
+}
+
+// Identity and permissions of remote token, scopes are empty when provider doesn't expose them
+type tokenInfo struct {
+	User      string
+	Scopes    []string
+	ExpiresAt string
+	Missing   []scopeRequirement
+}
+
+// Scopes required by a feature, any of them is enough
+type scopeRequirement struct {
+	Scopes []string
+	Usage  string
 }
 
 type gitRemote interface {
@@ -51,4 +102,80 @@ type gitRemote interface {
 	getGroups() ([]gitGroup, error)
 	getRepositories() ([]gitRepository, error)
 	createReviewRequest(repository *gitRepository, from string, into string, title string, message string, draft bool) (reviewRequest, error)
+	getTokenInfo() (tokenInfo, error)
+	getReviewRequest(repository *gitRepository, id string) (reviewRequest, error)
+	mergeReviewRequest(repository *gitRepository, id string, strategy string, deleteBranch bool) error
+	closeReviewRequest(repository *gitRepository, id string, deleteBranch bool) error
+	updateReviewRequest(repository *gitRepository, id string) error
+}
+
+// Remote resolving default branch on demand, when repository listing does not include it
+type defaultBranchResolver interface {
+	getDefaultBranch(repository *gitRepository) string
+}
+
+// Return combined status of checks, failure wins over pending which wins over success
+func combineChecks(checks ...string) string {
+	for _, status := range []string{CHECKS_FAILURE, CHECKS_PENDING, CHECKS_SUCCESS} {
+		if funk.ContainsString(checks, status) {
+			return status
+		}
+	}
+	return CHECKS_NONE
+}
+
+// Return requirements not satisfied by granted scopes
+func missingScopes(granted []string, requirements []scopeRequirement) []scopeRequirement {
+	return funk.Filter(requirements, func(requirement scopeRequirement) bool {
+		return len(funk.IntersectString(granted, requirement.Scopes)) == 0
+	}).([]scopeRequirement)
+}
+
+// Return next page number from Link header ( rel="next" ), 0 if there is no next page
+func parseNextPageValue(r *resty.Response) int {
+
+	nextLink := parseNextLink(r)
+	if nextLink == "" {
+		return 0
+	}
+
+	// try to pull out page parameter
+	parsedUrl, err := url.Parse(nextLink)
+	if err != nil {
+		return 0
+	}
+
+	page, _ := strconv.Atoi(parsedUrl.Query().Get("page"))
+	return page
+}
+
+// Return URL of next page from Link header ( rel="next" ), empty if there is no next page
+func parseNextLink(r *resty.Response) string {
+	link := r.Header().Get("Link")
+	if len(link) == 0 {
+		return ""
+	}
+
+	for _, link := range strings.Split(link, ",") {
+		segments := strings.Split(strings.TrimSpace(link), ";")
+
+		// link must at least have href and rel
+		if len(segments) < 2 {
+			continue
+		}
+
+		// ensure href is properly formatted
+		if !strings.HasPrefix(segments[0], "<") || !strings.HasSuffix(segments[0], ">") {
+			continue
+		}
+
+		for _, segment := range segments[1:] {
+			switch strings.TrimSpace(segment) {
+			case `rel="next"`:
+				return segments[0][1 : len(segments[0])-1]
+			}
+		}
+	}
+
+	return ""
 }
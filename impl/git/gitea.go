@@ -0,0 +1,487 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/thoas/go-funk"
+	"github.com/urfave/cli/v3"
+	"github.com/vanroy/microcli/impl/config"
+	"github.com/vanroy/microcli/impl/prompt"
+)
+
+const (
+	GT_PAGE_LIMIT = 50
+)
+
+type gitea struct {
+	config config.Config
+	labels Labels
+	apiUrl string
+}
+
+type gtOrg struct {
+	Id          int    `json:"id"`
+	UserName    string `json:"username"`
+	FullName    string `json:"full_name"`
+	Description string `json:"description"`
+	Visibility  string `json:"visibility"`
+}
+
+type gtOwner struct {
+	Id    int    `json:"id"`
+	Login string `json:"login"`
+}
+
+type gtRepo struct {
+	Id            int     `json:"id"`
+	Name          string  `json:"name"`
+	FullName      string  `json:"full_name"`
+	Description   string  `json:"description"`
+	HtmlUrl       string  `json:"html_url"`
+	HttpUrl       string  `json:"clone_url"`
+	SshUrl        string  `json:"ssh_url"`
+	DefaultBranch string  `json:"default_branch"`
+	Archived      bool    `json:"archived"`
+	Owner         gtOwner `json:"owner"`
+}
+
+type gtCreateOrg struct {
+	UserName    string `json:"username"`
+	FullName    string `json:"full_name"`
+	Description string `json:"description"`
+	Visibility  string `json:"visibility"`
+}
+
+type gtCreateRepository struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Private     bool   `json:"private"`
+}
+
+type gtCreatePullRequest struct {
+	Title string `json:"title"`
+	Head  string `json:"head"`
+	Base  string `json:"base"`
+	Body  string `json:"body"`
+}
+
+type gtPullRequest struct {
+	Id        int    `json:"id"`
+	Number    int    `json:"number"`
+	Title     string `json:"title"`
+	HtmlUrl   string `json:"html_url"`
+	State     string `json:"state"`
+	Merged    bool   `json:"merged"`
+	Mergeable bool   `json:"mergeable"`
+	Head      struct {
+		Ref string `json:"ref"`
+		Sha string `json:"sha"`
+	} `json:"head"`
+}
+
+type gtCommitStatus struct {
+	State      string `json:"state"`
+	TotalCount int    `json:"total_count"`
+}
+
+type gtReview struct {
+	State     string `json:"state"`
+	Dismissed bool   `json:"dismissed"`
+	Stale     bool   `json:"stale"`
+}
+
+var gtVisibilityOptions = []prompt.Option{
+	{Id: "public", Name: "Public"},
+	{Id: "limited", Name: "Limited"},
+	{Id: "private", Name: "Private"},
+}
+
+func newGitea(config config.Config) gitRemote {
+
+	baseUrl := config.Git.BaseUrl
+
+	if !strings.HasSuffix(baseUrl, "/") {
+		baseUrl += "/"
+	}
+	baseUrl += "api/v1"
+
+	return &gitea{
+		config: config,
+		labels: Labels{
+			GroupLabel:            "organization",
+			GroupsLabel:           "organizations",
+			RepositoryLabel:       "repository",
+			RepositoriesLabel:     "repositories",
+			CreateGroupUsage:      "name [full name] [description] [visibility]",
+			CreateRepositoryUsage: "[group] name [description] [visibility]",
+			CodeReviewRequest:     "pull request",
+		},
+		apiUrl: baseUrl,
+	}
+}
+
+func (gt *gitea) getLabels() Labels {
+	return gt.labels
+}
+
+func (gt *gitea) createGroup(args cli.Args) (string, error) {
+
+	orgaName := prompt.Input("Enter your organization name :", args.Get(0))
+	orgaFullName := prompt.Input("\nEnter your organization full name :", args.Get(1))
+	orgaDescription := prompt.Input("\nEnter your organization description :", args.Get(2))
+	orgaVisibility := prompt.Choice("\nSelect your organization visibility ( default: private ) :", gtVisibilityOptions, args.Get(3))
+	if orgaVisibility == "" {
+		orgaVisibility = "private"
+	}
+	prompt.PrintNewLine()
+
+	if orgaName == "" {
+		prompt.PrintErrorf("Missing parameters, organization name is required")
+		return "", errors.New("missing parameters")
+	}
+
+	data := gtCreateOrg{
+		UserName:    orgaName,
+		FullName:    orgaFullName,
+		Description: orgaDescription,
+		Visibility:  orgaVisibility,
+	}
+
+	createResp, err := gt.execPost("orgs", data, &gtOrg{})
+	if err != nil {
+		prompt.PrintErrorf("Cannot create organization. ( %s )", err.Error())
+		return "", err
+	}
+
+	prompt.PrintInfo("Organization created")
+
+	return createResp.(*gtOrg).UserName, nil
+}
+
+func (gt *gitea) createRepository(args cli.Args) (string, error) {
+
+	groups, _ := gt.getGroups()
+
+	groups = funk.Filter(groups, func(g gitGroup) bool { return funk.ContainsString(gt.config.Git.GroupIds, g.Id) }).([]gitGroup)
+
+	idx := 0
+	groupId := ""
+	if len(groups) == 0 {
+		prompt.PrintErrorf("No organization available")
+		return "", errors.New("no organization available")
+	} else if len(groups) == 1 {
+		groupId = groups[0].Id
+	} else {
+		defaultGroupId := ""
+		groupOptions := funk.Map(groups, func(group gitGroup) prompt.Option {
+			if args.Get(0) == group.Name {
+				defaultGroupId = group.Id
+			}
+			return prompt.Option{Id: group.Id, Name: group.Name}
+		}).([]prompt.Option)
+
+		groupId = prompt.Choice("Select your group :", groupOptions, defaultGroupId)
+		idx = idx + 1
+	}
+
+	projectName := prompt.Input("\nEnter your repository name :", args.Get(idx))
+	projectDescription := prompt.Input("\nEnter your repository description :", args.Get(idx+1))
+	projectVisibility := prompt.Choice("\nSelect your repository visibility ( default: private ) :", ghVisibilityOptions, args.Get(idx+2))
+
+	projectPrivate := projectVisibility != "public"
+
+	prompt.PrintNewLine()
+
+	if groupId == "" || projectName == "" {
+		prompt.PrintErrorf("Missing parameters, project name and group are required")
+		return "", errors.New("missing parameters")
+	}
+
+	data := gtCreateRepository{
+		Name:        projectName,
+		Description: projectDescription,
+		Private:     projectPrivate,
+	}
+	createResp, err := gt.execPost(gt.getGroupBasePath(groupId)+"/repos", data, &gtRepo{})
+
+	if err != nil {
+		prompt.PrintErrorf("Cannot create repository. ( %s )", err.Error())
+		return "", err
+	}
+
+	prompt.PrintInfo("Repository created")
+
+	return strconv.Itoa(createResp.(*gtRepo).Id), nil
+}
+
+func (gt *gitea) getGroups() ([]gitGroup, error) {
+
+	var groups []gitGroup
+
+	var curPage = 1
+
+	for {
+		resp, nextPage, err := gt.execGet("user/orgs?limit="+strconv.Itoa(GT_PAGE_LIMIT)+"&page="+strconv.Itoa(curPage), &[]gtOrg{})
+		if err != nil {
+			return nil, err
+		}
+
+		groups = append(groups, funk.Map(*resp.(*[]gtOrg), gt.toGitGroup).([]gitGroup)...)
+		if nextPage == 0 {
+			break
+		}
+		curPage = nextPage
+	}
+
+	// Add default personal group to allow user to read personal repositories
+	groups = append(groups, PERSONAL_GROUP)
+
+	return groups, nil
+}
+
+func (gt *gitea) getRepositories() ([]gitRepository, error) {
+
+	var repos []gitRepository
+
+	funk.ForEach(gt.config.Git.GroupIds, func(groupId string) {
+
+		var curPage = 1
+
+		for {
+			resp, nextPage, err := gt.execGet(gt.getGroupBasePath(groupId)+"/repos?limit="+strconv.Itoa(GT_PAGE_LIMIT)+"&page="+strconv.Itoa(curPage), &[]gtRepo{})
+			if err != nil {
+				break
+			}
+
+			filteredRepos := *resp.(*[]gtRepo)
+			if !gt.config.Git.IncludeArchivedProjects {
+				filteredRepos = funk.Filter(filteredRepos, func(repo gtRepo) bool { return !repo.Archived }).([]gtRepo)
+			}
+			repos = append(repos, funk.Map(filteredRepos, gt.toGitRepo(groupId)).([]gitRepository)...)
+			if nextPage == 0 {
+				break
+			}
+			curPage = nextPage
+		}
+	})
+
+	sort.Slice(repos, func(i, j int) bool {
+		return repos[i].NameWithNamespace < repos[j].NameWithNamespace
+	})
+
+	return repos, nil
+}
+
+func (gt *gitea) createReviewRequest(repository *gitRepository, from string, into string, title string, message string, draft bool) (reviewRequest, error) {
+
+	// Gitea has no draft flag, work in progress is detected with title prefix
+	if draft && !strings.HasPrefix(title, "WIP:") {
+		title = "WIP: " + title
+	}
+
+	data := gtCreatePullRequest{
+		Title: title,
+		Head:  from,
+		Base:  into,
+		Body:  message,
+	}
+
+	gtReview, err := gt.execPost("repos/"+repository.NameWithNamespace+"/pulls", data, &gtPullRequest{})
+	if err != nil {
+		return reviewRequest{}, err
+	}
+
+	return gt.toReviewRequest(gtReview.(*gtPullRequest)), nil
+}
+
+// Return pull request, with combined commit status and approvals still valid
+func (gt *gitea) getReviewRequest(repository *gitRepository, id string) (reviewRequest, error) {
+
+	repoPath := "repos/" + repository.NameWithNamespace
+
+	result, _, err := gt.execGet(repoPath+"/pulls/"+id, &gtPullRequest{})
+	if err != nil {
+		return reviewRequest{}, err
+	}
+	request := result.(*gtPullRequest)
+	review := gt.toReviewRequest(request)
+
+	status, _, err := gt.execGet(repoPath+"/commits/"+request.Head.Sha+"/status", &gtCommitStatus{})
+	if err != nil {
+		return reviewRequest{}, err
+	}
+	review.Checks = CHECKS_NONE
+	if status := status.(*gtCommitStatus); status.TotalCount > 0 {
+		switch status.State {
+		case "success", "warning":
+			review.Checks = CHECKS_SUCCESS
+		case "pending":
+			review.Checks = CHECKS_PENDING
+		default:
+			review.Checks = CHECKS_FAILURE
+		}
+	}
+
+	reviews, _, err := gt.execGet(repoPath+"/pulls/"+id+"/reviews", &[]gtReview{})
+	if err != nil {
+		return reviewRequest{}, err
+	}
+	for _, reviewer := range *reviews.(*[]gtReview) {
+		if reviewer.State == "APPROVED" && !reviewer.Dismissed && !reviewer.Stale {
+			review.Approvals++
+		}
+	}
+
+	return review, nil
+}
+
+func (gt *gitea) mergeReviewRequest(repository *gitRepository, id string, strategy string, deleteBranch bool) error {
+
+	data := map[string]interface{}{"Do": strategy, "delete_branch_after_merge": deleteBranch}
+	_, err := gt.execPost("repos/"+repository.NameWithNamespace+"/pulls/"+id+"/merge", data, nil)
+	return err
+}
+
+func (gt *gitea) closeReviewRequest(repository *gitRepository, id string, deleteBranch bool) error {
+
+	result, err := gt.execRequest(resty.MethodPatch, "repos/"+repository.NameWithNamespace+"/pulls/"+id, map[string]string{"state": "closed"}, &gtPullRequest{})
+	if err != nil || !deleteBranch {
+		return err
+	}
+
+	_, err = gt.execRequest(resty.MethodDelete, "repos/"+repository.NameWithNamespace+"/branches/"+url.PathEscape(result.(*gtPullRequest).Head.Ref), nil, nil)
+	return err
+}
+
+// Rebase head branch of pull request on base branch
+func (gt *gitea) updateReviewRequest(repository *gitRepository, id string) error {
+	_, err := gt.execPost("repos/"+repository.NameWithNamespace+"/pulls/"+id+"/update?style=rebase", nil, nil)
+	return err
+}
+
+// Return identity of token, scopes of Gitea tokens are not exposed
+func (gt *gitea) getTokenInfo() (tokenInfo, error) {
+
+	user, _, err := gt.execGet("user", &gtOrg{})
+	if err != nil {
+		return tokenInfo{}, err
+	}
+
+	return tokenInfo{User: user.(*gtOrg).UserName}, nil
+}
+
+func (gt *gitea) execGet(url string, resultType interface{}) (interface{}, int, error) {
+
+	resp, err := resty.New().R().
+		SetHeader("Authorization", "token "+gt.config.Git.PrivateToken).
+		SetHeader("Accept", "application/json").
+		SetResult(resultType).
+		Get(gt.apiUrl + "/" + url)
+
+	if err != nil {
+		prompt.PrintError(resp.String())
+		return nil, 0, err
+	}
+
+	if resp.StatusCode() < 200 || resp.StatusCode() > 300 {
+		return nil, 0, fmt.Errorf("cannot execute get request. Status code : %d. Message : %s", resp.StatusCode(), resp.String())
+	}
+
+	return resp.Result(), parseNextPageValue(resp), nil
+}
+
+func (gt *gitea) execPost(url string, data interface{}, resultType interface{}) (interface{}, error) {
+	return gt.execRequest(resty.MethodPost, url, data, resultType)
+}
+
+func (gt *gitea) execRequest(method string, url string, data interface{}, resultType interface{}) (interface{}, error) {
+
+	resp, err := resty.New().R().
+		SetHeader("Authorization", "token "+gt.config.Git.PrivateToken).
+		SetHeader("Accept", "application/json").
+		SetResult(resultType).
+		SetBody(data).
+		Execute(method, gt.apiUrl+"/"+url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode() < 200 || resp.StatusCode() > 300 {
+		return nil, fmt.Errorf("cannot execute %s request. Status code : %d. Message : %s", strings.ToLower(method), resp.StatusCode(), resp.String())
+	}
+
+	return resp.Result(), nil
+}
+
+func (gt *gitea) toGitGroup(org gtOrg) gitGroup {
+
+	return gitGroup{
+		Id:   org.UserName,
+		Name: org.UserName,
+	}
+}
+
+func (gt *gitea) toGitRepo(groupId string) interface{} {
+
+	return func(repository gtRepo) gitRepository {
+
+		return gitRepository{
+			Id:                strconv.Itoa(repository.Id),
+			Name:              repository.Name,
+			Description:       repository.Description,
+			NameWithNamespace: repository.FullName,
+			Path:              gt.normalize(repository.Name),
+			PathWithNamespace: gt.normalize(repository.FullName),
+			SshUrl:            repository.SshUrl,
+			HttpUrl:           repository.HttpUrl,
+			DefaultBranch:     repository.DefaultBranch,
+			Archived:          repository.Archived,
+			GroupId:           groupId,
+		}
+	}
+}
+
+func (gt *gitea) toReviewRequest(request *gtPullRequest) reviewRequest {
+
+	state := request.State
+	if request.Merged {
+		state = REVIEW_MERGED
+	} else if state == REVIEW_OPEN && strings.HasPrefix(request.Title, "WIP:") {
+		state = REVIEW_DRAFT
+	}
+
+	mergeable := MERGEABLE_CONFLICT
+	if request.Mergeable {
+		mergeable = MERGEABLE_YES
+	}
+
+	return reviewRequest{
+		Id:        strconv.Itoa(request.Number),
+		Title:     request.Title,
+		Url:       request.HtmlUrl,
+		State:     state,
+		Mergeable: mergeable,
+	}
+}
+
+func (gt *gitea) getGroupBasePath(groupId string) string {
+	if groupId == PERSONAL_GROUP.Id {
+		return "user"
+	} else {
+		return "orgs/" + groupId
+	}
+}
+
+func (gt *gitea) normalize(name string) string {
+	if !gt.config.Git.NormalizeName {
+		return name
+	}
+	return strings.ToLower(strings.ReplaceAll(name, " ", "-"))
+}
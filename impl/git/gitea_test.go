@@ -0,0 +1,78 @@
+package git
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vanroy/microcli/impl/config"
+)
+
+// Fake Gitea API serving two pages of repositories for organization "platform"
+func newFakeGitea(t *testing.T) *httptest.Server {
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/api/v1/orgs/platform/repos", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Authorization") != "token secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		repos := []gtRepo{{Id: 1, Name: "api", FullName: "platform/api", DefaultBranch: "main"}}
+		if r.URL.Query().Get("page") == "2" {
+			repos = []gtRepo{{Id: 2, Name: "legacy", FullName: "platform/legacy", Archived: true}}
+		} else {
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s/api/v1/orgs/platform/repos?page=2>; rel="next"`, r.Host))
+		}
+		json.NewEncoder(w).Encode(repos) //nolint:errcheck
+	})
+
+	mux.HandleFunc("/api/v1/repos/platform/api/pulls", func(w http.ResponseWriter, r *http.Request) {
+		var data gtCreatePullRequest
+		json.NewDecoder(r.Body).Decode(&data) //nolint:errcheck
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(gtPullRequest{Id: 42, Number: 7, Title: data.Title, State: "open", HtmlUrl: "http://gitea/platform/api/pulls/7"}) //nolint:errcheck
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func newTestGitea(t *testing.T) gitRemote {
+
+	server := newFakeGitea(t)
+	t.Cleanup(server.Close)
+
+	return newGitea(config.Config{Git: config.GitConfig{BaseUrl: server.URL, PrivateToken: "secret", GroupIds: []string{"platform"}}})
+}
+
+func TestGiteaGetRepositories(t *testing.T) {
+
+	repos, err := newTestGitea(t).getRepositories()
+	if err != nil {
+		t.Fatalf("Cannot retrieve repositories : %s", err.Error())
+	}
+
+	if len(repos) != 1 || repos[0].NameWithNamespace != "platform/api" || repos[0].GroupId != "platform" {
+		t.Errorf("Unexpected repositories %+v", repos)
+	}
+}
+
+func TestGiteaCreateReviewRequest(t *testing.T) {
+
+	repo := gitRepository{Name: "api", NameWithNamespace: "platform/api"}
+
+	review, err := newTestGitea(t).createReviewRequest(&repo, "feature", "main", "Update deps", "", true)
+	if err != nil {
+		t.Fatalf("Cannot create pull request : %s", err.Error())
+	}
+
+	if review.Id != "7" || review.Title != "WIP: Update deps" || review.State != REVIEW_DRAFT {
+		t.Errorf("Unexpected review request %+v", review)
+	}
+}
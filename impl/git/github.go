@@ -3,7 +3,7 @@ package git
 import (
 	"errors"
 	"fmt"
-	"net/url"
+	"net/http"
 	"sort"
 	"strconv"
 	"strings"
@@ -19,6 +19,7 @@ type gitHub struct {
 	config config.Config
 	labels Labels
 	apiUrl string
+	app    *ghAppTokens
 }
 
 type ghOrg struct {
@@ -73,9 +74,34 @@ type ghCreatePullRequest struct {
 
 type ghPullRequest struct {
 	Id        int    `json:"id"`
+	Number    int    `json:"number"`
+	Title     string `json:"title"`
 	HtmlUrl   string `json:"html_url"`
 	State     string `json:"state"`
-	Mergeable string `json:"mergeable"`
+	Draft     bool   `json:"draft"`
+	Merged    bool   `json:"merged"`
+	Mergeable *bool  `json:"mergeable"`
+	Head      struct {
+		Ref string `json:"ref"`
+		Sha string `json:"sha"`
+	} `json:"head"`
+}
+
+type ghCommitStatus struct {
+	State      string `json:"state"`
+	TotalCount int    `json:"total_count"`
+}
+
+type ghCheckRuns struct {
+	CheckRuns []struct {
+		Status     string `json:"status"`
+		Conclusion string `json:"conclusion"`
+	} `json:"check_runs"`
+}
+
+type ghReview struct {
+	State string `json:"state"`
+	User  ghOrg  `json:"user"`
 }
 
 func newGitHub(config config.Config) gitRemote {
@@ -103,6 +129,7 @@ func newGitHub(config config.Config) gitRemote {
 			CodeReviewRequest:     "pull request",
 		},
 		apiUrl: baseUrl,
+		app:    &ghAppTokens{tokens: map[string]ghInstallationToken{}},
 	}
 }
 
@@ -213,6 +240,17 @@ func (gh *gitHub) createRepository(args cli.Args) (string, error) {
 
 func (gh *gitHub) getGroups() ([]gitGroup, error) {
 
+	// GitHub App can only access organizations where it is installed
+	if gh.config.Git.AuthMode == GITHUB_APP_AUTH_MODE {
+		installations, err := gh.getInstallations()
+		if err != nil {
+			return nil, err
+		}
+		return funk.Map(installations, func(installation ghInstallation) gitGroup {
+			return gitGroup{Id: installation.Account.Login, Name: installation.Account.Login}
+		}).([]gitGroup), nil
+	}
+
 	resp, _, err := gh.execGet("user/orgs", &[]ghOrg{})
 	if err != nil {
 		return nil, err
@@ -269,7 +307,7 @@ func (gh *gitHub) createReviewRequest(repository *gitRepository, from string, in
 		Draft: draft,
 	}
 
-	ghReview, err := gh.execPost("repos/"+repository.GroupId+"/"+repository.Name+"/pulls", data, &ghPullRequest{})
+	ghReview, err := gh.execPost(gh.getRepositoryBasePath(repository)+"/pulls", data, &ghPullRequest{})
 	if err != nil {
 		return reviewRequest{}, err
 	}
@@ -277,10 +315,195 @@ func (gh *gitHub) createReviewRequest(repository *gitRepository, from string, in
 	return gh.toReviewRequest(ghReview.(*ghPullRequest)), err
 }
 
+// Return pull request, with combined status of commit statuses and check runs, and approvals of reviewers
+func (gh *gitHub) getReviewRequest(repository *gitRepository, id string) (reviewRequest, error) {
+
+	repoPath := gh.getRepositoryBasePath(repository)
+
+	pull, err := gh.getPullRequest(repoPath, id)
+	if err != nil {
+		return reviewRequest{}, err
+	}
+	review := gh.toReviewRequest(pull)
+
+	checks := []string{}
+	status, _, err := gh.execGet(repoPath+"/commits/"+pull.Head.Sha+"/status", &ghCommitStatus{})
+	if err != nil {
+		return reviewRequest{}, err
+	}
+	if status := status.(*ghCommitStatus); status.TotalCount > 0 {
+		checks = append(checks, ghCheckStatus(status.State))
+	}
+
+	runs, _, err := gh.execGet(repoPath+"/commits/"+pull.Head.Sha+"/check-runs?per_page=100", &ghCheckRuns{})
+	if err != nil {
+		return reviewRequest{}, err
+	}
+	for _, run := range runs.(*ghCheckRuns).CheckRuns {
+		if run.Status != "completed" {
+			checks = append(checks, CHECKS_PENDING)
+		} else {
+			checks = append(checks, ghCheckStatus(run.Conclusion))
+		}
+	}
+	review.Checks = combineChecks(checks...)
+
+	// Last review of each reviewer wins, approvals can be dismissed or followed by change requests
+	reviews, _, err := gh.execGet(repoPath+"/pulls/"+id+"/reviews?per_page=100", &[]ghReview{})
+	if err != nil {
+		return reviewRequest{}, err
+	}
+	states := map[string]string{}
+	for _, reviewer := range *reviews.(*[]ghReview) {
+		if reviewer.State != "COMMENTED" {
+			states[reviewer.User.Login] = reviewer.State
+		}
+	}
+	for _, state := range states {
+		if state == "APPROVED" {
+			review.Approvals++
+		}
+	}
+
+	return review, nil
+}
+
+// Merge pull request, only if its head is still the one checked
+func (gh *gitHub) mergeReviewRequest(repository *gitRepository, id string, strategy string, deleteBranch bool) error {
+
+	repoPath := gh.getRepositoryBasePath(repository)
+
+	pull, err := gh.getPullRequest(repoPath, id)
+	if err != nil {
+		return err
+	}
+
+	data := map[string]string{"merge_method": strategy, "sha": pull.Head.Sha}
+	if _, err := gh.execRequest(resty.MethodPut, repoPath+"/pulls/"+id+"/merge", data, &map[string]interface{}{}); err != nil {
+		return err
+	}
+
+	if deleteBranch {
+		return gh.deleteBranch(repoPath, pull.Head.Ref)
+	}
+	return nil
+}
+
+func (gh *gitHub) closeReviewRequest(repository *gitRepository, id string, deleteBranch bool) error {
+
+	repoPath := gh.getRepositoryBasePath(repository)
+
+	result, err := gh.execPatch(repoPath+"/pulls/"+id, map[string]string{"state": "closed"}, &ghPullRequest{})
+	if err != nil {
+		return err
+	}
+
+	if deleteBranch {
+		return gh.deleteBranch(repoPath, result.(*ghPullRequest).Head.Ref)
+	}
+	return nil
+}
+
+// Update head branch of pull request with base branch, GitHub API only merges it
+func (gh *gitHub) updateReviewRequest(repository *gitRepository, id string) error {
+
+	repoPath := gh.getRepositoryBasePath(repository)
+
+	pull, err := gh.getPullRequest(repoPath, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = gh.execRequest(resty.MethodPut, repoPath+"/pulls/"+id+"/update-branch", map[string]string{"expected_head_sha": pull.Head.Sha}, &map[string]interface{}{})
+	return err
+}
+
+func (gh *gitHub) getPullRequest(repoPath string, id string) (*ghPullRequest, error) {
+
+	result, _, err := gh.execGet(repoPath+"/pulls/"+id, &ghPullRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	pull := result.(*ghPullRequest)
+	if pull.Number == 0 {
+		return nil, fmt.Errorf("pull request #%s not found", id)
+	}
+
+	return pull, nil
+}
+
+func (gh *gitHub) deleteBranch(repoPath string, branch string) error {
+	_, err := gh.execRequest(resty.MethodDelete, repoPath+"/git/refs/heads/"+branch, nil, &map[string]interface{}{})
+	return err
+}
+
+// Return identity of token, scopes are only exposed for classic tokens
+func (gh *gitHub) getTokenInfo() (tokenInfo, error) {
+
+	if gh.config.Git.AuthMode == GITHUB_APP_AUTH_MODE {
+		installations, err := gh.getInstallations()
+		if err != nil {
+			return tokenInfo{}, err
+		}
+		return tokenInfo{User: fmt.Sprintf("GitHub App %s ( %d installations )", gh.config.Git.AppId, len(installations))}, nil
+	}
+
+	request, err := gh.request("user")
+	if err != nil {
+		return tokenInfo{}, err
+	}
+
+	resp, err := request.
+		SetHeader("Accept", "application/vnd.github+json").
+		SetHeader("X-GitHub-Api-Version", "2022-11-28").
+		SetResult(&ghOrg{}).
+		Get(gh.apiUrl + "/user")
+
+	if err != nil {
+		return tokenInfo{}, err
+	}
+	if resp.StatusCode() < 200 || resp.StatusCode() > 300 {
+		return tokenInfo{}, fmt.Errorf("cannot retrieve user. Status code : %d. Message : %s", resp.StatusCode(), resp.String())
+	}
+
+	info := tokenInfo{
+		User:      resp.Result().(*ghOrg).Login,
+		ExpiresAt: resp.Header().Get("GitHub-Authentication-Token-Expiration"),
+	}
+
+	// Fine-grained tokens have no scopes header, their permissions cannot be checked
+	if _, ok := resp.Header()[http.CanonicalHeaderKey("X-OAuth-Scopes")]; !ok {
+		return info, nil
+	}
+
+	for _, scope := range strings.Split(resp.Header().Get("X-OAuth-Scopes"), ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			info.Scopes = append(info.Scopes, scope)
+		}
+	}
+
+	requirements := []scopeRequirement{
+		{Scopes: []string{"repo"}, Usage: "gadd and pull requests"},
+		{Scopes: []string{"read:org", "write:org", "admin:org"}, Usage: "organizations listing"},
+	}
+	// Organizations can only be created by site administrators of GitHub Enterprise
+	if !strings.Contains(gh.apiUrl, "api.github.com") {
+		requirements = append(requirements, scopeRequirement{Scopes: []string{"site_admin"}, Usage: "ggadd"})
+	}
+	info.Missing = missingScopes(info.Scopes, requirements)
+
+	return info, nil
+}
+
 func (gh *gitHub) execGet(url string, resultType interface{}) (interface{}, int, error) {
 
-	resp, err := resty.New().R().
-		SetHeader("Authorization", "Bearer "+gh.config.Git.PrivateToken).
+	request, err := gh.request(url)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp, err := request.
 		SetHeader("Accept", "application/vnd.github+json").
 		SetHeader("X-GitHub-Api-Version", "2022-11-28").
 		SetResult(resultType).
@@ -291,50 +514,77 @@ func (gh *gitHub) execGet(url string, resultType interface{}) (interface{}, int,
 		return nil, 0, err
 	}
 
-	nextPage := gh.parseNextPageValue(resp)
+	nextPage := parseNextPageValue(resp)
 	return resp.Result(), nextPage, err
 }
 
 func (gh *gitHub) execPost(url string, data interface{}, resultType interface{}) (interface{}, error) {
+	return gh.execRequest(resty.MethodPost, url, data, resultType)
+}
+
+func (gh *gitHub) execPatch(url string, data interface{}, resultType interface{}) (interface{}, error) {
+	return gh.execRequest(resty.MethodPatch, url, data, resultType)
+}
 
-	resp, err := resty.New().R().
-		SetHeader("Authorization", "Bearer "+gh.config.Git.PrivateToken).
+func (gh *gitHub) execRequest(method string, url string, data interface{}, resultType interface{}) (interface{}, error) {
+
+	request, err := gh.request(url)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := request.
 		SetHeader("Accept", "application/vnd.github+json").
 		SetHeader("X-GitHub-Api-Version", "2022-11-28").
 		SetResult(resultType).
 		SetBody(data).
-		Post(gh.apiUrl + "/" + url)
+		Execute(method, gh.apiUrl+"/"+url)
 
 	if err != nil {
 		return nil, err
 	}
 
 	if resp.StatusCode() < 200 || resp.StatusCode() > 300 {
-		return nil, fmt.Errorf("cannot execute post request. Status code : %d. Message : %s", resp.StatusCode(), resp.String())
+		return nil, fmt.Errorf("cannot execute %s request. Status code : %d. Message : %s", strings.ToLower(method), resp.StatusCode(), resp.String())
 	}
 
 	return resp.Result(), nil
 }
 
-func (gh *gitHub) execPatch(url string, data interface{}, resultType interface{}) (interface{}, error) {
+// Return request authenticated with token of remote, or installation token of targeted organization for GitHub App
+func (gh *gitHub) request(url string) (*resty.Request, error) {
 
-	resp, err := resty.New().R().
-		SetHeader("Authorization", "Bearer "+gh.config.Git.PrivateToken).
-		SetHeader("Accept", "application/vnd.github+json").
-		SetHeader("X-GitHub-Api-Version", "2022-11-28").
-		SetResult(resultType).
-		SetBody(data).
-		Patch(gh.apiUrl + "/" + url)
-
-	if err != nil {
-		return nil, err
+	token := gh.config.Git.PrivateToken
+	if gh.config.Git.AuthMode == GITHUB_APP_AUTH_MODE {
+		installationToken, err := gh.installationToken(ghOwner(url))
+		if err != nil {
+			return nil, err
+		}
+		token = installationToken
 	}
 
-	if resp.StatusCode() < 200 || resp.StatusCode() > 300 {
-		return nil, fmt.Errorf("cannot execute post request. Status code : %d. Message : %s", resp.StatusCode(), resp.String())
+	r := newAuthClient(&gh.config.Git, gh.getOAuthFlow(), gh.authorize).R()
+	gh.authorize(r, token)
+	return r, nil
+}
+
+func (gh *gitHub) authorize(r *resty.Request, token string) {
+	r.SetHeader("Authorization", "Bearer "+token)
+}
+
+func (gh *gitHub) getOAuthFlow() *oauthFlow {
+
+	// Device login is served by web host ( github.com or GitHub Enterprise host )
+	webUrl := strings.TrimSuffix(gh.apiUrl, "/api/v3")
+	if strings.Contains(gh.apiUrl, "api.github.com") {
+		webUrl = "https://github.com"
 	}
 
-	return resp.Result(), nil
+	return &oauthFlow{
+		deviceCodeUrl: webUrl + "/login/device/code",
+		tokenUrl:      webUrl + "/login/oauth/access_token",
+		scopes:        "repo read:org",
+	}
 }
 
 func (gh *gitHub) toGitGroup(org ghOrg) gitGroup {
@@ -367,14 +617,47 @@ func (gh *gitHub) toGitRepo(groupId string) interface{} {
 
 func (gh *gitHub) toReviewRequest(request *ghPullRequest) reviewRequest {
 
+	state := request.State
+	if request.Merged {
+		state = REVIEW_MERGED
+	} else if state == REVIEW_OPEN && request.Draft {
+		state = REVIEW_DRAFT
+	}
+
+	// Mergeability is computed asynchronously, unknown until GitHub has computed it
+	mergeable := MERGEABLE_UNKNOWN
+	if request.Mergeable != nil && *request.Mergeable {
+		mergeable = MERGEABLE_YES
+	} else if request.Mergeable != nil {
+		mergeable = MERGEABLE_CONFLICT
+	}
+
 	return reviewRequest{
-		Id:        strconv.Itoa(request.Id),
+		Id:        strconv.Itoa(request.Number),
+		Title:     request.Title,
 		Url:       request.HtmlUrl,
-		State:     request.State,
-		Mergeable: request.Mergeable,
+		State:     state,
+		Mergeable: mergeable,
+	}
+}
+
+// Return normalized status of commit status or check run conclusion
+func ghCheckStatus(state string) string {
+	switch state {
+	case "success", "neutral", "skipped":
+		return CHECKS_SUCCESS
+	case "pending", "queued", "in_progress":
+		return CHECKS_PENDING
+	default:
+		return CHECKS_FAILURE
 	}
 }
 
+// Return API path of repository from its full name ( owner/name ), group id is not the owner of personal repositories
+func (gh *gitHub) getRepositoryBasePath(repository *gitRepository) string {
+	return "repos/" + repository.NameWithNamespace
+}
+
 func (gh *gitHub) getGroupBasePath(groupId string) string {
 	if groupId == PERSONAL_GROUP.Id {
 		return "user"
@@ -383,47 +666,6 @@ func (gh *gitHub) getGroupBasePath(groupId string) string {
 	}
 }
 
-func (gh *gitHub) parseNextPageValue(r *resty.Response) int {
-	link := r.Header().Get("Link")
-	if len(link) == 0 {
-		return 0
-	}
-
-	for _, link := range strings.Split(link, ",") {
-		segments := strings.Split(strings.TrimSpace(link), ";")
-
-		// link must at least have href and rel
-		if len(segments) < 2 {
-			continue
-		}
-
-		// ensure href is properly formatted
-		if !strings.HasPrefix(segments[0], "<") || !strings.HasSuffix(segments[0], ">") {
-			continue
-		}
-
-		// try to pull out page parameter
-		parsedUrl, err := url.Parse(segments[0][1 : len(segments[0])-1])
-		if err != nil {
-			continue
-		}
-		page := parsedUrl.Query().Get("page")
-		if page == "" {
-			continue
-		}
-
-		for _, segment := range segments[1:] {
-			switch strings.TrimSpace(segment) {
-			case `rel="next"`:
-				p, _ := strconv.Atoi(page)
-				return p
-			}
-		}
-	}
-
-	return 0
-}
-
 func (gh *gitHub) normalize(name string) string {
 	if !gh.config.Git.NormalizeName {
 		return name
@@ -0,0 +1,292 @@
+package git
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/vanroy/microcli/impl/cmd"
+	"github.com/vanroy/microcli/impl/config"
+	"github.com/vanroy/microcli/impl/prompt"
+	"github.com/vanroy/microcli/impl/secret"
+)
+
+const (
+	GITHUB_APP_AUTH_MODE = "github-app"
+	GITHUB_APP_USERNAME  = "x-access-token"
+
+	// Installation tokens are renewed when expiring within this delay
+	GITHUB_APP_TOKEN_RENEWAL = 5 * time.Minute
+	GITHUB_APP_JWT_VALIDITY  = 9 * time.Minute
+)
+
+var githubAppAuthMode = prompt.Option{Id: GITHUB_APP_AUTH_MODE, Name: "GitHub App"}
+
+type ghInstallation struct {
+	Id      int `json:"id"`
+	Account struct {
+		Login string `json:"login"`
+		Type  string `json:"type"`
+	} `json:"account"`
+}
+
+type ghInstallationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Installations and tokens of GitHub App, by account login
+type ghAppTokens struct {
+	lock          sync.Mutex
+	installations map[string]int
+	tokens        map[string]ghInstallationToken
+	// Secret store cannot keep tokens ( unavailable keychain, failing store ), tokens are only kept in memory
+	storeChecked  bool
+	storeDisabled bool
+}
+
+// Return installation token of account ( organization ), renewed before expiry
+func (gh *gitHub) installationToken(owner string) (string, error) {
+
+	if owner == "" {
+		return "", errors.New("GitHub App requests must target an organization")
+	}
+
+	gh.app.lock.Lock()
+	defer gh.app.lock.Unlock()
+
+	if token, ok := gh.app.tokens[owner]; ok && time.Until(token.ExpiresAt) > GITHUB_APP_TOKEN_RENEWAL {
+		return token.Token, nil
+	}
+
+	// Tokens are kept in secret store, so short-lived commands ( ex: credential helper ) don't request a new one
+	// An unavailable keychain is reported by doctor, not on each command
+	if !gh.app.storeChecked {
+		gh.app.storeChecked = true
+		gh.app.storeDisabled = (gh.config.Git.SecretStore == "" || gh.config.Git.SecretStore == secret.KEYRING) && secret.CheckKeyring() != nil
+	}
+	if !gh.app.storeDisabled {
+		if token, err := gh.loadInstallationToken(owner); err == nil && time.Until(token.ExpiresAt) > GITHUB_APP_TOKEN_RENEWAL {
+			cmd.AddSecret(token.Token)
+			gh.app.tokens[owner] = token
+			return token.Token, nil
+		}
+	}
+
+	if gh.app.installations == nil {
+		installations, err := gh.getInstallations()
+		if err != nil {
+			return "", err
+		}
+		gh.app.installations = map[string]int{}
+		for _, installation := range installations {
+			gh.app.installations[strings.ToLower(installation.Account.Login)] = installation.Id
+		}
+	}
+
+	installationId, ok := gh.app.installations[strings.ToLower(owner)]
+	if !ok {
+		return "", fmt.Errorf("GitHub App is not installed on '%s'", owner)
+	}
+
+	request, err := gh.appRequest()
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := request.SetResult(&ghInstallationToken{}).Post(gh.apiUrl + "/app/installations/" + strconv.Itoa(installationId) + "/access_tokens")
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode() < 200 || resp.StatusCode() > 300 {
+		return "", fmt.Errorf("cannot create installation token. Status code : %d. Message : %s", resp.StatusCode(), resp.String())
+	}
+
+	token := *resp.Result().(*ghInstallationToken)
+	cmd.AddSecret(token.Token)
+	gh.app.tokens[owner] = token
+
+	if gh.app.storeDisabled {
+		return token.Token, nil
+	}
+	// Failure is warned once, next tokens are only kept in memory
+	if err := gh.saveInstallationToken(owner, token); err != nil && !errors.Is(err, secret.ErrReadOnly) {
+		prompt.PrintWarn("Cannot save installation token, tokens will be requested on each command ( %s )", err.Error())
+		gh.app.storeDisabled = true
+	}
+
+	return token.Token, nil
+}
+
+// Return installation token of account saved in secret store
+func (gh *gitHub) loadInstallationToken(owner string) (ghInstallationToken, error) {
+
+	var token ghInstallationToken
+
+	value, err := config.GetSecret(gh.config.Git, appTokenSecret(owner))
+	if err != nil {
+		return token, err
+	}
+
+	err = json.Unmarshal([]byte(value), &token)
+	return token, err
+}
+
+// Save installation token of account in secret store, with its expiry date
+func (gh *gitHub) saveInstallationToken(owner string, token ghInstallationToken) error {
+
+	value, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	return config.SaveSecret(gh.config.Git, appTokenSecret(owner), string(value))
+}
+
+// Return name of installation token secret of account
+func appTokenSecret(owner string) string {
+	return config.APP_TOKEN_SECRET + "/" + strings.ToLower(owner)
+}
+
+// Return installations of GitHub App, from all pages
+func (gh *gitHub) getInstallations() ([]ghInstallation, error) {
+
+	var installations []ghInstallation
+
+	for page := 1; page != 0; {
+		request, err := gh.appRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := request.SetResult(&[]ghInstallation{}).Get(gh.apiUrl + "/app/installations?per_page=100&page=" + strconv.Itoa(page))
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode() < 200 || resp.StatusCode() > 300 {
+			return nil, fmt.Errorf("cannot retrieve installations. Status code : %d. Message : %s", resp.StatusCode(), resp.String())
+		}
+
+		installations = append(installations, *resp.Result().(*[]ghInstallation)...)
+		page = parseNextPageValue(resp)
+	}
+
+	return installations, nil
+}
+
+// Return request authenticated as GitHub App, with a signed JWT
+func (gh *gitHub) appRequest() (*resty.Request, error) {
+
+	jwt, err := gh.appJwt(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return resty.New().R().
+		SetHeader("Authorization", "Bearer "+jwt).
+		SetHeader("Accept", "application/vnd.github+json").
+		SetHeader("X-GitHub-Api-Version", "2022-11-28"), nil
+}
+
+// Return JWT of GitHub App, signed with its private key ( RS256 )
+func (gh *gitHub) appJwt(now time.Time) (string, error) {
+
+	key, err := readPrivateKey(gh.appPrivateKeyPath())
+	if err != nil {
+		return "", err
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		// Issued in the past to allow clock drift
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(GITHUB_APP_JWT_VALIDITY).Unix(),
+		"iss": gh.config.Git.AppId,
+	})
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Return path of private key, relative paths are resolved from workspace
+func (gh *gitHub) appPrivateKeyPath() string {
+
+	path := gh.config.Git.AppPrivateKeyPath
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	workspaceDir, err := config.WorkspaceDir()
+	if err != nil {
+		return path
+	}
+
+	return filepath.Join(workspaceDir, path)
+}
+
+// Read RSA private key from PEM file ( PKCS1 as generated by GitHub, or PKCS8 )
+func readPrivateKey(path string) (*rsa.PrivateKey, error) {
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read private key ( %s )", err.Error())
+	}
+
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM private key found in '%s'", path)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key in '%s' ( %s )", path, err.Error())
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key in '%s' is not a RSA key", path)
+	}
+
+	return rsaKey, nil
+}
+
+// Return owner ( organization / user ) targeted by API path, empty if none ( ex: orgs/acme/repos -> acme )
+func ghOwner(path string) string {
+
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+
+	switch parts[0] {
+	case "orgs", "repos", "users":
+		return strings.SplitN(parts[1], "?", 2)[0]
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,119 @@
+package git
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vanroy/microcli/impl/config"
+)
+
+func TestGitHubAppInstallationToken(t *testing.T) {
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "app.pem")
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(keyPath, keyPem, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tokenRequests := 0
+	mux := http.NewServeMux()
+
+	writeJson := func(w http.ResponseWriter, value interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(value) //nolint:errcheck
+	}
+
+	mux.HandleFunc("/api/v3/app/installations", func(w http.ResponseWriter, r *http.Request) {
+		claims := map[string]interface{}{}
+		parts := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".")
+		if len(parts) == 3 {
+			payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+			json.Unmarshal(payload, &claims) //nolint:errcheck
+		}
+		if claims["iss"] != "42" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		// Installations are paginated with Link header
+		if r.URL.Query().Get("page") == "2" {
+			writeJson(w, []map[string]interface{}{{"id": 8, "account": map[string]string{"login": "Globex", "type": "Organization"}}})
+			return
+		}
+		w.Header().Set("Link", `<`+"http://"+r.Host+`/api/v3/app/installations?per_page=100&page=2>; rel="next"`)
+		writeJson(w, []map[string]interface{}{{"id": 7, "account": map[string]string{"login": "Acme", "type": "Organization"}}})
+	})
+
+	mux.HandleFunc("/api/v3/app/installations/7/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		writeJson(w, ghInstallationToken{Token: "ghs_installation", ExpiresAt: time.Now().Add(time.Hour)})
+	})
+
+	mux.HandleFunc("/api/v3/orgs/acme/repos", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer ghs_installation" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		writeJson(w, []ghRepo{{Name: "api"}})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	gh := newGitHub(config.Config{Git: config.GitConfig{
+		Type:              "github",
+		BaseUrl:           server.URL,
+		AuthMode:          GITHUB_APP_AUTH_MODE,
+		AppId:             "42",
+		AppPrivateKeyPath: keyPath,
+		// Only installation token of 'stored' account is available in secret store
+		SecretStore:   "command",
+		SecretCommand: `[ "${MBX_SECRET_USER##*/}" = stored ] && echo '{"token":"ghs_stored","expires_at":"2099-01-01T00:00:00Z"}'`,
+	}}).(*gitHub)
+
+	groups, err := gh.getGroups()
+	if err != nil || len(groups) != 2 || groups[0].Id != "Acme" || groups[1].Id != "Globex" {
+		t.Fatalf("expected installations of all pages as groups, got %v ( %v )", groups, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := gh.execGet("orgs/acme/repos", &[]ghRepo{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if tokenRequests != 1 {
+		t.Errorf("expected installation token to be cached, got %d requests", tokenRequests)
+	}
+
+	if token, err := gh.installationToken("Stored"); err != nil || token != "ghs_stored" || tokenRequests != 1 {
+		t.Errorf("expected installation token from secret store, got '%s' ( %v )", token, err)
+	}
+
+	if _, err := gh.installationToken("other"); err == nil {
+		t.Errorf("expected error for organization without installation")
+	}
+	if gh.app.storeDisabled {
+		t.Errorf("read-only secret store must still be used to read tokens")
+	}
+
+	// Failing secret store is warned once, then tokens are only kept in memory
+	failing := newGitHub(config.Config{Git: config.GitConfig{Type: "github", BaseUrl: server.URL, AuthMode: GITHUB_APP_AUTH_MODE, AppId: "42", AppPrivateKeyPath: keyPath, SecretStore: "unknown"}}).(*gitHub)
+	if token, err := failing.installationToken("Acme"); err != nil || token != "ghs_installation" || !failing.app.storeDisabled {
+		t.Errorf("expected token without secret store, got '%s' ( %v )", token, err)
+	}
+}
@@ -0,0 +1,144 @@
+package git
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vanroy/microcli/impl/config"
+)
+
+func TestGitHubTokenInfo(t *testing.T) {
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/user", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Header.Get("Authorization") {
+		case "Bearer classic":
+			w.Header().Set("X-OAuth-Scopes", "repo, gist")
+			w.Header().Set("GitHub-Authentication-Token-Expiration", "2030-01-01 00:00:00 UTC")
+		case "Bearer fine-grained":
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(ghOrg{Login: "john"}) //nolint:errcheck
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	tokenInfo := func(token string) (tokenInfo, error) {
+		return newGitHub(config.Config{Git: config.GitConfig{Type: "github", BaseUrl: server.URL, PrivateToken: token}}).getTokenInfo()
+	}
+
+	info, err := tokenInfo("classic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.User != "john" || len(info.Scopes) != 2 || info.ExpiresAt != "2030-01-01 00:00:00 UTC" {
+		t.Errorf("unexpected token info %+v", info)
+	}
+	// GitHub Enterprise requires read:org and site_admin
+	if len(info.Missing) != 2 || info.Missing[0].Usage != "organizations listing" || info.Missing[1].Usage != "ggadd" {
+		t.Errorf("unexpected missing scopes %+v", info.Missing)
+	}
+
+	info, err = tokenInfo("fine-grained")
+	if err != nil || info.User != "john" || len(info.Missing) != 0 {
+		t.Errorf("expected fine-grained token without scope check, got %+v ( %v )", info, err)
+	}
+
+	if _, err := tokenInfo("bad"); err == nil {
+		t.Errorf("expected error for rejected token")
+	}
+}
+
+func TestGitHubReviewRequest(t *testing.T) {
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/acme/api/pulls/12", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 9001, "number": 12, "title": "Bump", "state": "open", "draft": false, "merged": false, "mergeable": true, "head": {"sha": "abc"}}`)) //nolint:errcheck
+	})
+	mux.HandleFunc("/api/v3/repos/acme/api/commits/abc/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"state": "success", "total_count": 1}`)) //nolint:errcheck
+	})
+	mux.HandleFunc("/api/v3/repos/acme/api/commits/abc/check-runs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"check_runs": [{"status": "completed", "conclusion": "success"}, {"status": "in_progress"}]}`)) //nolint:errcheck
+	})
+	mux.HandleFunc("/api/v3/repos/acme/api/pulls/12/reviews", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"state": "APPROVED", "user": {"login": "john"}}, {"state": "CHANGES_REQUESTED", "user": {"login": "john"}}, {"state": "APPROVED", "user": {"login": "jane"}}, {"state": "COMMENTED", "user": {"login": "jane"}}]`)) //nolint:errcheck
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	gh := newGitHub(config.Config{Git: config.GitConfig{Type: "github", BaseUrl: server.URL, PrivateToken: "token"}})
+	review, err := gh.getReviewRequest(&gitRepository{GroupId: "acme", Name: "api", NameWithNamespace: "acme/api"}, "12")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if review.Id != "12" || review.State != REVIEW_OPEN || review.Mergeable != MERGEABLE_YES || review.Checks != CHECKS_PENDING || review.Approvals != 1 {
+		t.Errorf("unexpected review request %+v", review)
+	}
+}
+
+func TestGitHubMergeReviewRequest(t *testing.T) {
+
+	var merge map[string]string
+	deleted := ""
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/repos/acme/api/pulls/12", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"number": 12, "state": "open", "head": {"ref": "bump-7", "sha": "abc"}}`)) //nolint:errcheck
+	})
+	mux.HandleFunc("PUT /api/v3/repos/acme/api/pulls/12/merge", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&merge) //nolint:errcheck
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"merged": true}`)) //nolint:errcheck
+	})
+	mux.HandleFunc("DELETE /api/v3/repos/acme/api/git/refs/heads/", func(w http.ResponseWriter, r *http.Request) {
+		deleted = r.URL.Path
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	gh := newGitHub(config.Config{Git: config.GitConfig{Type: "github", BaseUrl: server.URL, PrivateToken: "token"}})
+	if err := gh.mergeReviewRequest(&gitRepository{GroupId: "acme", Name: "api", NameWithNamespace: "acme/api"}, "12", MERGE_STRATEGY_SQUASH, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if merge["merge_method"] != "squash" || merge["sha"] != "abc" {
+		t.Errorf("unexpected merge request %v", merge)
+	}
+	if deleted != "/api/v3/repos/acme/api/git/refs/heads/bump-7" {
+		t.Errorf("expected source branch to be deleted, got '%s'", deleted)
+	}
+
+	if err := gh.mergeReviewRequest(&gitRepository{GroupId: "acme", Name: "web", NameWithNamespace: "acme/web"}, "12", MERGE_STRATEGY_MERGE, false); err == nil {
+		t.Errorf("expected error for missing pull request")
+	}
+}
+
+func TestGitHubRepositoryBasePath(t *testing.T) {
+
+	gh := &gitHub{}
+
+	// Personal repositories are owned by user, not by personal group
+	personal := &gitRepository{GroupId: PERSONAL_GROUP.Id, Name: "dotfiles", NameWithNamespace: "john/dotfiles"}
+	if path := gh.getRepositoryBasePath(personal); path != "repos/john/dotfiles" {
+		t.Errorf("unexpected personal repository path '%s'", path)
+	}
+	if path := gh.getRepositoryBasePath(&gitRepository{GroupId: "acme", Name: "api", NameWithNamespace: "acme/api"}); path != "repos/acme/api" {
+		t.Errorf("unexpected organization repository path '%s'", path)
+	}
+}
@@ -3,8 +3,11 @@ package git
 import (
 	"errors"
 	"fmt"
+	"net/url"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/thoas/go-funk"
@@ -13,6 +16,10 @@ import (
 	"github.com/vanroy/microcli/impl/prompt"
 )
 
+const (
+	GL_PAGE_SIZE = 100
+)
+
 type gitLab struct {
 	config config.Config
 	labels Labels
@@ -48,6 +55,40 @@ type glProject struct {
 	Archived          bool   `json:"archived"`
 }
 
+type glMergeRequest struct {
+	Id                  int    `json:"id"`
+	Iid                 int    `json:"iid"`
+	Title               string `json:"title"`
+	State               string `json:"state"`
+	MergeStatus         string `json:"merge_status"`
+	DetailedMergeStatus string `json:"detailed_merge_status"`
+	Draft               bool   `json:"draft"`
+	WebUrl              string `json:"web_url"`
+	SourceBranch        string `json:"source_branch"`
+	HeadPipeline        *struct {
+		Status string `json:"status"`
+	} `json:"head_pipeline"`
+}
+
+type glApprovals struct {
+	ApprovedBy []struct {
+		User glUser `json:"user"`
+	} `json:"approved_by"`
+}
+
+type glUser struct {
+	Id       int    `json:"id"`
+	Username string `json:"username"`
+}
+
+type glTokenInfo struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	Scope     []string `json:"scope"`
+	ExpiresAt string   `json:"expires_at"`
+	ExpiresIn int      `json:"expires_in"`
+}
+
 var glVisibilityOptions = []prompt.Option{
 	{Id: "public", Name: "Public"},
 	{Id: "private", Name: "Private"},
@@ -182,12 +223,18 @@ func (gl *gitLab) createRepository(args cli.Args) (string, error) {
 
 func (gl *gitLab) getGroups() ([]gitGroup, error) {
 
-	resp, err := gl.execGet("groups?per_page=100", &[]glGroup{})
-	if err != nil {
-		return nil, err
-	}
+	var groups []gitGroup
 
-	groups := funk.Map(*resp.(*[]glGroup), gl.toGitGroup).([]gitGroup)
+	pageUrl := "groups?per_page=" + strconv.Itoa(GL_PAGE_SIZE)
+	for pageUrl != "" {
+		resp, nextUrl, err := gl.execGet(pageUrl, &[]glGroup{})
+		if err != nil {
+			return nil, err
+		}
+
+		groups = append(groups, funk.Map(*resp.(*[]glGroup), gl.toGitGroup).([]gitGroup)...)
+		pageUrl = nextUrl
+	}
 
 	// Add default personal group to allow user to read personal repositories
 	groups = append(groups, PERSONAL_GROUP)
@@ -201,14 +248,42 @@ func (gl *gitLab) getRepositories() ([]gitRepository, error) {
 
 	funk.ForEach(gl.config.Git.GroupIds, func(groupId string) {
 
-		resp, err := gl.execGet(gl.getGroupBasePath(groupId)+"/projects?order_by=name&sort=asc&per_page=100", &[]glProject{})
-		if err == nil {
+		// Keyset pagination is only documented for /projects ( personal group ), group projects use offset pagination
+		// Keyset pagination is only available when ordering by id, projects of group are sorted once all pages are retrieved
+		keyset := gl.config.Git.KeysetPagination && groupId == PERSONAL_GROUP.Id
+
+		query := "order_by=name&sort=asc"
+		if keyset {
+			query = "pagination=keyset&order_by=id&sort=asc"
+		}
+
+		var groupRepos []gitRepository
+		pageUrl := gl.getProjectsPath(groupId) + "?" + query + "&per_page=" + strconv.Itoa(GL_PAGE_SIZE)
+		if groupId == PERSONAL_GROUP.Id {
+			pageUrl += "&membership=true"
+		} else if gl.config.Git.IncludeSubgroups {
+			pageUrl += "&include_subgroups=true"
+		}
+		for pageUrl != "" {
+			resp, nextUrl, err := gl.execGet(pageUrl, &[]glProject{})
+			if err != nil {
+				break
+			}
+
 			filteredRepos := *resp.(*[]glProject)
 			if !gl.config.Git.IncludeArchivedProjects {
 				filteredRepos = funk.Filter(filteredRepos, func(repo glProject) bool { return !repo.Archived }).([]glProject)
 			}
-			repos = append(repos, funk.Map(filteredRepos, gl.toGitRepo(groupId)).([]gitRepository)...)
+			groupRepos = append(groupRepos, funk.Map(filteredRepos, gl.toGitRepo(groupId)).([]gitRepository)...)
+			pageUrl = nextUrl
 		}
+
+		if keyset {
+			sort.SliceStable(groupRepos, func(i, j int) bool {
+				return strings.ToLower(groupRepos[i].Name) < strings.ToLower(groupRepos[j].Name)
+			})
+		}
+		repos = append(repos, groupRepos...)
 	})
 
 	return repos, nil
@@ -216,43 +291,201 @@ func (gl *gitLab) getRepositories() ([]gitRepository, error) {
 
 func (gl *gitLab) createReviewRequest(repository *gitRepository, from string, into string, title string, message string, draft bool) (reviewRequest, error) {
 
-	// TODO ( Implement )
-	return reviewRequest{}, nil
+	if draft && !strings.HasPrefix(title, "Draft:") {
+		title = "Draft: " + title
+	}
+
+	data := map[string]string{
+		"source_branch": from,
+		"target_branch": into,
+		"title":         title,
+		"description":   message,
+	}
+
+	glReview, err := gl.execPost("projects/"+repository.Id+"/merge_requests", data, &glMergeRequest{})
+	if err != nil {
+		return reviewRequest{}, err
+	}
+
+	return gl.toReviewRequest(glReview.(*glMergeRequest)), nil
+}
+
+// Return merge request, with status of head pipeline and approvals
+func (gl *gitLab) getReviewRequest(repository *gitRepository, id string) (reviewRequest, error) {
+
+	result, _, err := gl.execGet("projects/"+repository.Id+"/merge_requests/"+id, &glMergeRequest{})
+	if err != nil {
+		return reviewRequest{}, err
+	}
+	request := result.(*glMergeRequest)
+	review := gl.toReviewRequest(request)
+
+	review.Checks = CHECKS_NONE
+	if request.HeadPipeline != nil {
+		switch request.HeadPipeline.Status {
+		case "success", "skipped":
+			review.Checks = CHECKS_SUCCESS
+		case "failed", "canceled":
+			review.Checks = CHECKS_FAILURE
+		default:
+			review.Checks = CHECKS_PENDING
+		}
+	}
+
+	approvals, _, err := gl.execGet("projects/"+repository.Id+"/merge_requests/"+id+"/approvals", &glApprovals{})
+	if err != nil {
+		return reviewRequest{}, err
+	}
+	review.Approvals = len(approvals.(*glApprovals).ApprovedBy)
+
+	return review, nil
+}
+
+// Merge merge request, merge commit or fast-forward is a project setting and only squash can be chosen
+func (gl *gitLab) mergeReviewRequest(repository *gitRepository, id string, strategy string, deleteBranch bool) error {
+
+	if strategy == MERGE_STRATEGY_REBASE {
+		return errors.New("rebase strategy is not available on GitLab merge, set fast-forward merge method on project")
+	}
+
+	data := map[string]string{
+		"squash":                      strconv.FormatBool(strategy == MERGE_STRATEGY_SQUASH),
+		"should_remove_source_branch": strconv.FormatBool(deleteBranch),
+	}
+
+	_, err := gl.execRequest(resty.MethodPut, "projects/"+repository.Id+"/merge_requests/"+id+"/merge", data, &glMergeRequest{})
+	return err
+}
+
+func (gl *gitLab) closeReviewRequest(repository *gitRepository, id string, deleteBranch bool) error {
+
+	result, err := gl.execRequest(resty.MethodPut, "projects/"+repository.Id+"/merge_requests/"+id, map[string]string{"state_event": "close"}, &glMergeRequest{})
+	if err != nil {
+		return err
+	}
+
+	if deleteBranch {
+		_, err = gl.execRequest(resty.MethodDelete, "projects/"+repository.Id+"/repository/branches/"+url.PathEscape(result.(*glMergeRequest).SourceBranch), nil, nil)
+	}
+	return err
+}
+
+// Rebase source branch of merge request on target branch, rebase is done asynchronously by GitLab
+func (gl *gitLab) updateReviewRequest(repository *gitRepository, id string) error {
+	_, err := gl.execRequest(resty.MethodPut, "projects/"+repository.Id+"/merge_requests/"+id+"/rebase", nil, nil)
+	return err
 }
 
-func (gl *gitLab) execGet(url string, resultType interface{}) (interface{}, error) {
+// Return identity and scopes of token, from personal access token or OAuth token introspection
+func (gl *gitLab) getTokenInfo() (tokenInfo, error) {
+
+	user, _, err := gl.execGet("user", &glUser{})
+	if err != nil {
+		return tokenInfo{}, err
+	}
+
+	tokenUrl := "personal_access_tokens/self"
+	if gl.config.Git.AuthMode == OAUTH_AUTH_MODE {
+		tokenUrl = strings.TrimSuffix(gl.apiUrl, "/api/v4") + "/oauth/token/info"
+	}
+
+	token, _, err := gl.execGet(tokenUrl, &glTokenInfo{})
+	if err != nil {
+		return tokenInfo{}, err
+	}
+
+	glToken := token.(*glTokenInfo)
+	info := tokenInfo{
+		User:      user.(*glUser).Username,
+		Scopes:    append(glToken.Scopes, glToken.Scope...),
+		ExpiresAt: glToken.ExpiresAt,
+	}
+	if glToken.ExpiresIn > 0 {
+		info.ExpiresAt = time.Now().Add(time.Duration(glToken.ExpiresIn) * time.Second).Format(time.RFC3339)
+	}
+
+	info.Missing = missingScopes(info.Scopes, []scopeRequirement{
+		{Scopes: []string{"api", "read_api"}, Usage: "groups and projects listing"},
+		{Scopes: []string{"api"}, Usage: "gadd, ggadd and merge requests"},
+	})
 
-	resp, err := resty.New().R().
-		SetHeader("PRIVATE-TOKEN", gl.config.Git.PrivateToken).
+	return info, nil
+}
+
+// Execute GET request, return result and URL of next page ( empty if last page )
+func (gl *gitLab) execGet(url string, resultType interface{}) (interface{}, string, error) {
+
+	// Next page URLs from Link header are absolute
+	requestUrl := url
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		requestUrl = gl.apiUrl + "/" + url
+	}
+
+	resp, err := gl.request().
 		SetResult(resultType).
-		Get(gl.apiUrl + "/" + url)
+		Get(requestUrl)
 
 	if err != nil {
 		prompt.PrintError(resp.String())
+		return nil, "", err
+	}
+
+	if resp.StatusCode() < 200 || resp.StatusCode() > 300 {
+		return nil, "", fmt.Errorf("cannot execute get request. Status code : %d. Message : %s", resp.StatusCode(), resp.String())
 	}
 
-	return resp.Result(), err
+	return resp.Result(), gl.parseNextPageUrl(requestUrl, resp), nil
 }
 
 func (gl *gitLab) execPost(url string, data map[string]string, resultType interface{}) (interface{}, error) {
+	return gl.execRequest(resty.MethodPost, url, data, resultType)
+}
 
-	resp, err := resty.New().R().
-		SetHeader("PRIVATE-TOKEN", gl.config.Git.PrivateToken).
+func (gl *gitLab) execRequest(method string, url string, data map[string]string, resultType interface{}) (interface{}, error) {
+
+	resp, err := gl.request().
 		SetResult(resultType).
 		SetFormData(data).
-		Post(gl.apiUrl + "/" + url)
+		Execute(method, gl.apiUrl+"/"+url)
 
 	if err != nil {
 		return nil, err
 	}
 
 	if resp.StatusCode() < 200 || resp.StatusCode() > 300 {
-		return nil, fmt.Errorf("cannot execute post request. Status code : %d. Message : %s", resp.StatusCode(), resp.String())
+		return nil, fmt.Errorf("cannot execute %s request. Status code : %d. Message : %s", strings.ToLower(method), resp.StatusCode(), resp.String())
 	}
 
 	return resp.Result(), nil
 }
 
+// Return request authenticated with token of remote
+func (gl *gitLab) request() *resty.Request {
+	r := newAuthClient(&gl.config.Git, gl.getOAuthFlow(), gl.authorize).R()
+	gl.authorize(r, gl.config.Git.PrivateToken)
+	return r
+}
+
+// Set token on request, OAuth tokens are given as bearer
+func (gl *gitLab) authorize(r *resty.Request, token string) {
+	if gl.config.Git.AuthMode == OAUTH_AUTH_MODE {
+		r.SetHeader("Authorization", "Bearer "+token)
+	} else {
+		r.SetHeader("PRIVATE-TOKEN", token)
+	}
+}
+
+func (gl *gitLab) getOAuthFlow() *oauthFlow {
+
+	baseUrl := strings.TrimSuffix(gl.apiUrl, "/api/v4")
+
+	return &oauthFlow{
+		deviceCodeUrl: baseUrl + "/oauth/authorize_device",
+		tokenUrl:      baseUrl + "/oauth/token",
+		scopes:        "api",
+	}
+}
+
 func (gl *gitLab) toGitGroup(group glGroup) gitGroup {
 
 	return gitGroup{
@@ -281,11 +514,62 @@ func (gl *gitLab) toGitRepo(groupId string) interface{} {
 	}
 }
 
-func (gh *gitLab) getGroupBasePath(groupId string) string {
-	if groupId == PERSONAL_GROUP.Id {
+func (gl *gitLab) toReviewRequest(request *glMergeRequest) reviewRequest {
+
+	state := REVIEW_CLOSED
+	if request.State == "opened" && request.Draft {
+		state = REVIEW_DRAFT
+	} else if request.State == "opened" {
+		state = REVIEW_OPEN
+	} else if request.State == "merged" {
+		state = REVIEW_MERGED
+	}
+
+	mergeable := MERGEABLE_UNKNOWN
+	if request.DetailedMergeStatus == "conflict" || request.DetailedMergeStatus == "need_rebase" || strings.HasPrefix(request.MergeStatus, "cannot_be_merged") {
+		mergeable = MERGEABLE_CONFLICT
+	} else if request.MergeStatus == "can_be_merged" {
+		mergeable = MERGEABLE_YES
+	}
+
+	return reviewRequest{
+		Id:        strconv.Itoa(request.Iid),
+		Title:     request.Title,
+		Url:       request.WebUrl,
+		State:     state,
+		Mergeable: mergeable,
+	}
+}
+
+// Return URL of next page, from Link header ( offset and keyset pagination ) or X-Next-Page header
+func (gl *gitLab) parseNextPageUrl(requestUrl string, r *resty.Response) string {
+
+	if nextLink := parseNextLink(r); nextLink != "" {
+		return nextLink
+	}
+
+	nextPage := r.Header().Get("X-Next-Page")
+	if nextPage == "" {
+		return ""
+	}
+
+	parsedUrl, err := url.Parse(requestUrl)
+	if err != nil {
 		return ""
+	}
+
+	query := parsedUrl.Query()
+	query.Set("page", nextPage)
+	parsedUrl.RawQuery = query.Encode()
+
+	return parsedUrl.String()
+}
+
+func (gh *gitLab) getProjectsPath(groupId string) string {
+	if groupId == PERSONAL_GROUP.Id {
+		return "projects"
 	} else {
-		return "groups/" + groupId
+		return "groups/" + groupId + "/projects"
 	}
 }
 
@@ -0,0 +1,192 @@
+package git
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/vanroy/microcli/impl/config"
+)
+
+// Fake GitLab API, group projects use offset pagination ( X-Next-Page header ) and personal projects use keyset pagination ( Link header )
+// Merge requests can be created on project 7
+func newFakeGitLab(t *testing.T) *httptest.Server {
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/api/v4/groups/platform/projects", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		// Keyset pagination is not documented for group projects
+		if r.URL.Query().Get("pagination") == "keyset" || r.URL.Query().Get("order_by") != "name" {
+			t.Errorf("Group projects must use offset pagination ordered by name : %s", r.URL.RawQuery)
+		}
+
+		projects := []glProject{{Id: 2, Name: "web", PathWithNamespace: "platform/web"}}
+		if r.URL.Query().Get("page") == "" {
+			projects = []glProject{{Id: 1, Name: "Api", PathWithNamespace: "platform/api"}}
+			w.Header().Set("X-Next-Page", "2")
+		}
+		json.NewEncoder(w).Encode(projects) //nolint:errcheck
+	})
+
+	mux.HandleFunc("/api/v4/projects", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("membership") != "true" || r.URL.Query().Get("pagination") != "keyset" {
+			t.Errorf("Personal projects must be restricted to membership with keyset pagination : %s", r.URL.RawQuery)
+		}
+
+		// Keyset pages are ordered by id
+		projects := []glProject{{Id: 4, Name: "blog", PathWithNamespace: "john/blog"}}
+		if r.URL.Query().Get("id_after") == "" {
+			projects = []glProject{{Id: 3, Name: "dotfiles", PathWithNamespace: "john/dotfiles"}}
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s/api/v4/projects?pagination=keyset&membership=true&id_after=3>; rel="next"`, r.Host))
+		}
+		json.NewEncoder(w).Encode(projects) //nolint:errcheck
+	})
+
+	mux.HandleFunc("POST /api/v4/projects/7/merge_requests", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.FormValue("source_branch") != "bump" || r.FormValue("target_branch") != "main" {
+			t.Errorf("Unexpected merge request branches : %v", r.Form)
+		}
+
+		title := r.FormValue("title")
+		json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+			"iid":          12,
+			"title":        title,
+			"state":        "opened",
+			"draft":        strings.HasPrefix(title, "Draft:"),
+			"merge_status": "can_be_merged",
+			"web_url":      "https://gitlab.example.com/platform/api/-/merge_requests/12",
+		})
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestGitLabGetRepositories(t *testing.T) {
+
+	server := newFakeGitLab(t)
+	t.Cleanup(server.Close)
+
+	gitlab := newGitLab(config.Config{Git: config.GitConfig{BaseUrl: server.URL, PrivateToken: "secret", KeysetPagination: true, GroupIds: []string{"platform", PERSONAL_GROUP.Id}}})
+
+	repos, err := gitlab.getRepositories()
+	if err != nil {
+		t.Fatalf("Cannot retrieve repositories : %s", err.Error())
+	}
+
+	names := make([]string, len(repos))
+	for i, repo := range repos {
+		names[i] = repo.PathWithNamespace
+	}
+	if fmt.Sprint(names) != "[platform/api platform/web john/blog john/dotfiles]" {
+		t.Errorf("Unexpected repositories %v", names)
+	}
+}
+
+func TestGitLabParseNextPageUrl(t *testing.T) {
+
+	gitlab := &gitLab{}
+	requestUrl := "https://gitlab.com/api/v4/projects?membership=true&per_page=100"
+
+	tests := []struct {
+		name     string
+		header   http.Header
+		expected string
+	}{
+		{"link", http.Header{"Link": {`<https://gitlab.com/api/v4/projects?page=1>; rel="first", <https://gitlab.com/api/v4/projects?page=2>; rel="next"`}}, "https://gitlab.com/api/v4/projects?page=2"},
+		{"keyset", http.Header{"Link": {`<https://gitlab.com/api/v4/projects?pagination=keyset&id_after=42>; rel="next"`}}, "https://gitlab.com/api/v4/projects?pagination=keyset&id_after=42"},
+		{"next page", http.Header{"X-Next-Page": {"3"}}, "https://gitlab.com/api/v4/projects?membership=true&page=3&per_page=100"},
+		{"last page", http.Header{"X-Next-Page": {""}, "Link": {`<https://gitlab.com/api/v4/projects?page=1>; rel="first"`}}, ""},
+	}
+
+	for _, test := range tests {
+		resp := &resty.Response{RawResponse: &http.Response{Header: test.header}}
+		if nextUrl := gitlab.parseNextPageUrl(requestUrl, resp); nextUrl != test.expected {
+			t.Errorf("%s : unexpected next page url '%s'", test.name, nextUrl)
+		}
+	}
+}
+
+func TestGitLabMergeReviewRequest(t *testing.T) {
+
+	var merges []string
+	var deletedBranches []string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /api/v4/projects/7/merge_requests/12/merge", func(w http.ResponseWriter, r *http.Request) {
+		merges = append(merges, "squash="+r.FormValue("squash")+" remove="+r.FormValue("should_remove_source_branch"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"iid": 12, "state": "merged"}`)) //nolint:errcheck
+	})
+	mux.HandleFunc("PUT /api/v4/projects/7/merge_requests/12", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("state_event") != "close" {
+			t.Errorf("unexpected state event '%s'", r.FormValue("state_event"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"iid": 12, "state": "closed", "source_branch": "mbx/bump"}`)) //nolint:errcheck
+	})
+	mux.HandleFunc("DELETE /api/v4/projects/7/repository/branches/{branch}", func(w http.ResponseWriter, r *http.Request) {
+		deletedBranches = append(deletedBranches, r.PathValue("branch"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	gitlab := newGitLab(config.Config{Git: config.GitConfig{BaseUrl: server.URL, PrivateToken: "secret"}})
+	repo := &gitRepository{Id: "7"}
+
+	if err := gitlab.mergeReviewRequest(repo, "12", MERGE_STRATEGY_SQUASH, true); err != nil {
+		t.Fatalf("Cannot merge : %s", err.Error())
+	}
+	if err := gitlab.mergeReviewRequest(repo, "12", MERGE_STRATEGY_MERGE, false); err != nil {
+		t.Fatalf("Cannot merge : %s", err.Error())
+	}
+	if fmt.Sprint(merges) != "[squash=true remove=true squash=false remove=false]" {
+		t.Errorf("Unexpected merge requests %v", merges)
+	}
+
+	// Merge method is a project setting on GitLab
+	if err := gitlab.mergeReviewRequest(repo, "12", MERGE_STRATEGY_REBASE, false); err == nil || len(merges) != 2 {
+		t.Errorf("Expected rebase strategy to be rejected without request")
+	}
+
+	if err := gitlab.closeReviewRequest(repo, "12", true); err != nil {
+		t.Fatalf("Cannot close : %s", err.Error())
+	}
+	if fmt.Sprint(deletedBranches) != "[mbx/bump]" {
+		t.Errorf("Unexpected deleted branches %v", deletedBranches)
+	}
+}
+
+func TestGitLabCreateReviewRequest(t *testing.T) {
+
+	server := newFakeGitLab(t)
+	t.Cleanup(server.Close)
+
+	gitlab := newGitLab(config.Config{Git: config.GitConfig{BaseUrl: server.URL, PrivateToken: "secret"}})
+	repo := &gitRepository{Id: "7"}
+
+	review, err := gitlab.createReviewRequest(repo, "bump", "main", "Bump deps", "", false)
+	if err != nil {
+		t.Fatalf("Cannot create merge request : %s", err.Error())
+	}
+	expected := reviewRequest{Id: "12", Title: "Bump deps", Url: "https://gitlab.example.com/platform/api/-/merge_requests/12", State: REVIEW_OPEN, Mergeable: MERGEABLE_YES}
+	if review != expected {
+		t.Errorf("Unexpected review request %+v", review)
+	}
+
+	// Draft merge requests are marked by title prefix, not added twice
+	for _, title := range []string{"Bump deps", "Draft: Bump deps"} {
+		review, err = gitlab.createReviewRequest(repo, "bump", "main", title, "", true)
+		if err != nil || review.Title != "Draft: Bump deps" || review.State != REVIEW_DRAFT {
+			t.Errorf("Unexpected draft review request %+v ( %v )", review, err)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/thoas/go-funk"
+	"github.com/urfave/cli/v3"
+	"github.com/vanroy/microcli/impl/config"
+	"github.com/vanroy/microcli/impl/file"
+	"github.com/vanroy/microcli/impl/output"
+	"github.com/vanroy/microcli/impl/prompt"
+)
+
+type groupRecord struct {
+	Id       string `json:"id" yaml:"id"`
+	Name     string `json:"name" yaml:"name"`
+	Remote   string `json:"remote" yaml:"remote"`
+	Selected bool   `json:"selected" yaml:"selected"`
+}
+
+// List available groups on remote, and the ones selected in workspace
+func (g *GitCommands) GroupList(_ context.Context, c *cli.Command) error {
+
+	r, err := g.selectRemote(c)
+	if err != nil {
+		return err
+	}
+
+	groups, err := r.impl.getGroups()
+	if err != nil {
+		prompt.PrintErrorf("Cannot retrieve %s (%s)", r.impl.getLabels().GroupsLabel, err.Error())
+		return err
+	}
+
+	records := funk.Map(groups, func(group gitGroup) groupRecord {
+		return groupRecord{Id: group.Id, Name: group.Name, Remote: r.name, Selected: funk.ContainsString(r.config.Git.GroupIds, group.Id)}
+	}).([]groupRecord)
+
+	if output.IsStructured() {
+		return output.Print(records)
+	}
+
+	funk.ForEach(records, func(record groupRecord) {
+		if record.Selected {
+			prompt.PrintItem(record.Name + " " + prompt.Color(prompt.FgGreen) + "[selected]" + prompt.Color(prompt.Reset))
+		} else {
+			prompt.PrintItem(record.Name)
+		}
+	})
+
+	return nil
+}
+
+// Add groups to workspace, selected by name / id in arguments or by prompt
+func (g *GitCommands) GroupAdd(_ context.Context, c *cli.Command) error {
+
+	r, err := g.selectRemote(c)
+	if err != nil {
+		return err
+	}
+
+	labels := r.impl.getLabels()
+
+	groups, err := r.impl.getGroups()
+	if err != nil {
+		prompt.PrintErrorf("Cannot retrieve %s (%s)", labels.GroupsLabel, err.Error())
+		return err
+	}
+
+	available := funk.Filter(groups, func(group gitGroup) bool { return !funk.ContainsString(r.config.Git.GroupIds, group.Id) }).([]gitGroup)
+
+	groupIds, err := selectGroups(available, c.Args().Slice(), "Select "+labels.GroupsLabel+" to add on workspace :")
+	if err != nil {
+		return err
+	}
+
+	if len(groupIds) == 0 {
+		prompt.PrintWarn("No %s selected", labels.GroupLabel)
+		return nil
+	}
+
+	return updateConfig(func(conf *config.Config) error {
+		remoteConf := workspaceRemote(conf, r)
+		remoteConf.GroupIds = append(remoteConf.GroupIds, groupIds...)
+		return nil
+	})
+}
+
+// Remove group from workspace, local clones of its repositories can be listed or removed
+// Last group cannot be removed, workspace is initialized with at least one group
+func (g *GitCommands) GroupRemove(_ context.Context, c *cli.Command) error {
+
+	r, err := g.selectRemote(c)
+	if err != nil {
+		return err
+	}
+
+	labels := r.impl.getLabels()
+
+	// Candidates are workspace groups, remote is only used to name them ( group can be deleted or no longer visible )
+	groups, err := r.impl.getGroups()
+	if err != nil {
+		prompt.PrintWarn("Cannot retrieve %s (%s)", labels.GroupsLabel, err.Error())
+	}
+
+	selected := funk.Map(r.config.Git.GroupIds, func(id string) gitGroup {
+		if group := funk.Find(groups, func(group gitGroup) bool { return group.Id == id }); group != nil {
+			return group.(gitGroup)
+		}
+		return gitGroup{Id: id, Name: id}
+	}).([]gitGroup)
+
+	groupIds, err := selectGroups(selected, c.Args().Slice(), "Select "+labels.GroupsLabel+" to remove from workspace :")
+	if err != nil {
+		return err
+	}
+
+	if len(groupIds) == 0 {
+		prompt.PrintWarn("No %s selected", labels.GroupLabel)
+		return nil
+	}
+
+	// Workspace without group would fall back to groups of user config, as before it was initialized
+	if len(funk.FilterString(r.config.Git.GroupIds, func(id string) bool { return !funk.ContainsString(groupIds, id) })) == 0 {
+		prompt.PrintErrorf("Cannot remove all %s, workspace requires at least one %s", labels.GroupsLabel, labels.GroupLabel)
+		return errors.New("cannot remove all groups")
+	}
+
+	err = updateConfig(func(conf *config.Config) error {
+		remoteConf := workspaceRemote(conf, r)
+		remoteConf.GroupIds = funk.FilterString(remoteConf.GroupIds, func(id string) bool { return !funk.ContainsString(groupIds, id) })
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return g.cleanupGroupClones(r, groupIds)
+}
+
+// Offer to list or remove local clones of repositories belonging to groups
+func (g *GitCommands) cleanupGroupClones(r *remote, groupIds []string) error {
+
+	repos, err := r.impl.getRepositories()
+	if err != nil {
+		return err
+	}
+
+	var folders []string
+	funk.ForEach(repos, func(repo gitRepository) {
+		if funk.ContainsString(groupIds, repo.GroupId) && file.Exist(g.localPath(repo)) {
+			folders = append(folders, g.localPath(repo))
+		}
+	})
+
+	if len(folders) == 0 {
+		return nil
+	}
+
+	prompt.PrintInfo("%d local %s belong to removed %s", len(folders), g.GetLabels().RepositoriesLabel, g.GetLabels().GroupsLabel)
+
+	// Local clones are kept in non-interactive mode
+	if !config.Options.Interactive {
+		return nil
+	}
+
+	for {
+		response := prompt.RestrictedInput("List, remove or keep local clones?", []string{"l", "r", "k"})
+		switch response {
+		case "l":
+			funk.ForEach(folders, func(folder string) { prompt.PrintItem(folder) })
+		case "r":
+			funk.ForEach(folders, func(folder string) {
+				record := g.toLocalRecord(folder, false)
+				if record.Dirty || record.Stashes > 0 {
+					prompt.PrintWarn("'%s' has local changes, %skeeping", folder, prompt.Color(prompt.FgYellow))
+					return
+				}
+				if err := os.RemoveAll(file.Rel(folder)); err != nil {
+					prompt.PrintErrorf("Cannot remove '%s' ( %s )", folder, err.Error())
+					return
+				}
+				prompt.PrintInfo("'%s' %sremoved", folder, prompt.Color(prompt.FgBlue))
+			})
+			return nil
+		case "k":
+			return nil
+		}
+	}
+}
+
+// Return settings of remote in workspace config, group ids default to effective ones when defined by user config or environment
+func workspaceRemote(conf *config.Config, r *remote) *config.GitConfig {
+
+	remoteConf := &conf.Git
+	if r.name != config.DEFAULT_REMOTE {
+		if conf.Remotes == nil {
+			conf.Remotes = map[string]*config.GitConfig{}
+		}
+		// Remote only declared in user config, copy it to workspace without secret
+		if conf.Remotes[r.name] == nil {
+			effective := r.config.Git
+			effective.PrivateToken = ""
+			effective.Jobs = 0
+			effective.Layout = ""
+			conf.Remotes[r.name] = &effective
+		}
+		remoteConf = conf.Remotes[r.name]
+	}
+
+	if len(remoteConf.GroupIds) == 0 {
+		remoteConf.GroupIds = append([]string{}, r.config.Git.GroupIds...)
+	}
+
+	return remoteConf
+}
+
+// Return ids of groups matching with names / ids, or selected by prompt if none given
+func selectGroups(groups []gitGroup, names []string, label string) ([]string, error) {
+
+	if len(names) == 0 {
+		options := funk.Map(groups, func(group gitGroup) prompt.Option {
+			return prompt.Option{Id: group.Id, Name: group.Name}
+		}).([]prompt.Option)
+
+		groupIds := prompt.MultiChoice(label, options)
+		prompt.PrintNewLine()
+		return groupIds, nil
+	}
+
+	var groupIds []string
+	for _, name := range names {
+		group := funk.Find(groups, func(group gitGroup) bool { return group.Id == name || group.Name == name })
+		if group == nil {
+			prompt.PrintErrorf("Unknown or already selected '%s'", name)
+			return nil, errors.New("unknown group")
+		}
+		groupIds = append(groupIds, group.(gitGroup).Id)
+	}
+
+	return groupIds, nil
+}
@@ -0,0 +1,87 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v3"
+	"github.com/vanroy/microcli/impl/config"
+	"github.com/vanroy/microcli/impl/prompt"
+)
+
+func TestGroupAddRemove(t *testing.T) {
+
+	root := newLocalRoot(t, "platform/api", "platform/web", "tools/cli")
+	newSettingsWorkspace(t, "[Git]\nType = \"local\"\nBaseUrl = \""+root+"\"\nAuthMode = \"none\"\nGroupIds = [\"platform\"]\n")
+	workspace, _ := os.Getwd()
+
+	t.Cleanup(func() { prompt.SetInput(os.Stdin) })
+
+	// Each command loads config, as a new mbx process
+	run := func(name string, action func(*GitCommands) cli.ActionFunc, answers string, args ...string) error {
+		prompt.SetInput(strings.NewReader(answers))
+		return (&cli.Command{Name: name, Action: action(NewCommands(*mustLoad(t)))}).Run(context.Background(), append([]string{name}, args...))
+	}
+	add := func(g *GitCommands) cli.ActionFunc { return g.GroupAdd }
+	remove := func(g *GitCommands) cli.ActionFunc { return g.GroupRemove }
+	groupIds := func() []string { return mustLoad(t).Git.GroupIds }
+	exist := func(folder string) bool {
+		_, err := os.Stat(filepath.Join(workspace, folder))
+		return err == nil
+	}
+
+	if err := run("add", add, "", "tools"); err != nil {
+		t.Fatal(err)
+	}
+	if err := run("add", add, "", "platform"); err == nil {
+		t.Errorf("expected already selected group to be rejected")
+	}
+	if !reflect.DeepEqual(groupIds(), []string{"platform", "tools"}) {
+		t.Fatalf("unexpected groups %v", groupIds())
+	}
+
+	if _, err := NewCommands(*mustLoad(t)).cloneRepos("", nil, false, 1); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(workspace, "web", "notes"), nil, 0644) //nolint:errcheck
+
+	// Clones are listed then removed, except the ones with local changes
+	if err := run("remove", remove, "l\nr\n", "platform"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(groupIds(), []string{"tools"}) {
+		t.Errorf("unexpected groups %v", groupIds())
+	}
+	if exist("api") || !exist("web") || !exist("cli") {
+		t.Errorf("expected clean clone of removed group to be removed only")
+	}
+
+	// Last group is kept, user config groups would be used again otherwise
+	if err := run("remove", remove, "", "tools"); err == nil {
+		t.Errorf("expected last group removal to be rejected")
+	}
+	if !reflect.DeepEqual(groupIds(), []string{"tools"}) || !exist("cli") {
+		t.Errorf("unexpected groups %v after rejected removal", groupIds())
+	}
+
+	// Clones are kept on request, and without prompt in non-interactive mode
+	if err := run("add", add, "", "platform"); err != nil {
+		t.Fatal(err)
+	}
+	if err := run("remove", remove, "k\n", "platform"); err != nil || !exist("web") {
+		t.Errorf("expected clones to be kept ( %v )", err)
+	}
+
+	config.Options.Interactive = false
+	t.Cleanup(func() { config.Options.Interactive = true })
+	if err := run("add", add, "", "platform"); err != nil {
+		t.Fatal(err)
+	}
+	if err := run("remove", remove, "", "platform"); err != nil || !exist("web") {
+		t.Errorf("expected clones to be kept in non-interactive mode ( %v )", err)
+	}
+}
@@ -0,0 +1,247 @@
+package git
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/thoas/go-funk"
+	"github.com/urfave/cli/v3"
+	"github.com/vanroy/microcli/impl/cmd"
+	"github.com/vanroy/microcli/impl/config"
+	"github.com/vanroy/microcli/impl/prompt"
+)
+
+const (
+	LOCAL_DEFAULT_DESCRIPTION = "Unnamed repository; edit this file 'description' to name the repository."
+)
+
+type local struct {
+	config   config.Config
+	labels   Labels
+	rootPath string
+}
+
+func newLocal(config config.Config) gitRemote {
+
+	rootPath := config.Git.BaseUrl
+
+	if parsedUrl, err := url.Parse(rootPath); err == nil && parsedUrl.Scheme == "file" {
+		rootPath = parsedUrl.Path
+	}
+
+	return &local{
+		config: config,
+		labels: Labels{
+			GroupLabel:            "directory",
+			GroupsLabel:           "directories",
+			RepositoryLabel:       "repository",
+			RepositoriesLabel:     "repositories",
+			CreateGroupUsage:      "name",
+			CreateRepositoryUsage: "[directory] name [description]",
+			CodeReviewRequest:     "review request",
+		},
+		rootPath: filepath.Clean(rootPath),
+	}
+}
+
+func (lc *local) getLabels() Labels {
+	return lc.labels
+}
+
+func (lc *local) createGroup(args cli.Args) (string, error) {
+
+	groupName := prompt.Input("Enter your directory name :", args.Get(0))
+	prompt.PrintNewLine()
+
+	if groupName == "" {
+		prompt.PrintErrorf("Missing parameters, directory name is required")
+		return "", errors.New("missing parameters")
+	}
+
+	if err := os.MkdirAll(filepath.Join(lc.rootPath, groupName), 0755); err != nil {
+		prompt.PrintErrorf("Cannot create directory. ( %s )", err.Error())
+		return "", err
+	}
+
+	prompt.PrintInfo("Directory created")
+
+	return groupName, nil
+}
+
+func (lc *local) createRepository(args cli.Args) (string, error) {
+
+	groups, _ := lc.getGroups()
+
+	groups = funk.Filter(groups, func(g gitGroup) bool { return funk.ContainsString(lc.config.Git.GroupIds, g.Id) }).([]gitGroup)
+
+	idx := 0
+	groupId := ""
+	if len(groups) == 0 {
+		prompt.PrintErrorf("No directories available")
+		return "", errors.New("no directories available")
+	} else if len(groups) == 1 {
+		groupId = groups[0].Id
+	} else {
+		defaultGroupId := ""
+		groupOptions := funk.Map(groups, func(group gitGroup) prompt.Option {
+			if args.Get(0) == group.Name {
+				defaultGroupId = group.Id
+			}
+			return prompt.Option{Id: group.Id, Name: group.Name}
+		}).([]prompt.Option)
+
+		groupId = prompt.Choice("Select your directory :", groupOptions, defaultGroupId)
+		idx = idx + 1
+	}
+
+	repositoryName := prompt.Input("\nEnter your repository name :", args.Get(idx))
+	repositoryDescription := prompt.Input("\nEnter your repository description :", args.Get(idx+1))
+	prompt.PrintNewLine()
+
+	if groupId == "" || repositoryName == "" {
+		prompt.PrintErrorf("Missing parameters, repository name and directory are required")
+		return "", errors.New("missing parameters")
+	}
+
+	repositoryId := groupId + "/" + strings.TrimSuffix(repositoryName, ".git") + ".git"
+	repositoryPath := filepath.Join(lc.rootPath, repositoryId)
+
+	if out, err := cmd.ExecCmd("git", []string{"init", "-q", "--bare", repositoryPath}); err != nil {
+		prompt.PrintErrorf("Cannot create repository. ( %s )", cmd.ErrorString(out))
+		return "", err
+	}
+
+	if repositoryDescription != "" {
+		if err := os.WriteFile(filepath.Join(repositoryPath, "description"), []byte(repositoryDescription+"\n"), 0644); err != nil {
+			prompt.PrintWarn("Cannot write repository description ( %s )", err.Error())
+		}
+	}
+
+	prompt.PrintInfo("Repository created")
+
+	return repositoryId, nil
+}
+
+func (lc *local) getGroups() ([]gitGroup, error) {
+
+	entries, err := os.ReadDir(lc.rootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []gitGroup
+
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || isBareRepository(filepath.Join(lc.rootPath, entry.Name())) {
+			continue
+		}
+		groups = append(groups, gitGroup{Id: entry.Name(), Name: entry.Name()})
+	}
+
+	return groups, nil
+}
+
+func (lc *local) getRepositories() ([]gitRepository, error) {
+
+	var repos []gitRepository
+
+	funk.ForEach(lc.config.Git.GroupIds, func(groupId string) {
+
+		entries, err := os.ReadDir(filepath.Join(lc.rootPath, groupId))
+		if err != nil {
+			return
+		}
+
+		for _, entry := range entries {
+			repositoryPath := filepath.Join(lc.rootPath, groupId, entry.Name())
+			if entry.IsDir() && isBareRepository(repositoryPath) {
+				repos = append(repos, lc.toGitRepo(groupId, repositoryPath))
+			}
+		}
+	})
+
+	sort.Slice(repos, func(i, j int) bool {
+		return repos[i].NameWithNamespace < repos[j].NameWithNamespace
+	})
+
+	return repos, nil
+}
+
+func (lc *local) createReviewRequest(repository *gitRepository, from string, into string, title string, message string, draft bool) (reviewRequest, error) {
+	return reviewRequest{}, errors.New("review requests are not supported on local filesystem")
+}
+
+func (lc *local) getReviewRequest(repository *gitRepository, id string) (reviewRequest, error) {
+	return reviewRequest{}, errors.New("review requests are not supported on local filesystem")
+}
+
+func (lc *local) mergeReviewRequest(repository *gitRepository, id string, strategy string, deleteBranch bool) error {
+	return errors.New("review requests are not supported on local filesystem")
+}
+
+func (lc *local) closeReviewRequest(repository *gitRepository, id string, deleteBranch bool) error {
+	return errors.New("review requests are not supported on local filesystem")
+}
+
+func (lc *local) updateReviewRequest(repository *gitRepository, id string) error {
+	return errors.New("review requests are not supported on local filesystem")
+}
+
+// Local remotes have no token
+func (lc *local) getTokenInfo() (tokenInfo, error) {
+	return tokenInfo{}, nil
+}
+
+func (lc *local) toGitRepo(groupId string, repositoryPath string) gitRepository {
+
+	name := strings.TrimSuffix(filepath.Base(repositoryPath), ".git")
+
+	description := ""
+	if content, err := os.ReadFile(filepath.Join(repositoryPath, "description")); err == nil {
+		description = strings.TrimSpace(string(content))
+		if description == LOCAL_DEFAULT_DESCRIPTION {
+			description = ""
+		}
+	}
+
+	defaultBranch, _ := cmd.ExecCmd("git", []string{"--git-dir", repositoryPath, "symbolic-ref", "--short", "HEAD"})
+
+	return gitRepository{
+		Id:                groupId + "/" + filepath.Base(repositoryPath),
+		Name:              name,
+		Description:       description,
+		NameWithNamespace: groupId + " / " + name,
+		Path:              lc.normalize(name),
+		PathWithNamespace: lc.normalize(groupId + "/" + name),
+		SshUrl:            repositoryPath,
+		HttpUrl:           repositoryPath,
+		DefaultBranch:     strings.TrimSpace(defaultBranch),
+		Archived:          false,
+		GroupId:           groupId,
+	}
+}
+
+func (lc *local) normalize(name string) string {
+	if !lc.config.Git.NormalizeName {
+		return name
+	}
+	return strings.ToLower(strings.ReplaceAll(name, " ", "-"))
+}
+
+// Return true if folder looks like a bare GIT repository
+func isBareRepository(path string) bool {
+
+	if _, err := os.Stat(filepath.Join(path, "HEAD")); err != nil {
+		return false
+	}
+
+	if info, err := os.Stat(filepath.Join(path, "objects")); err != nil || !info.IsDir() {
+		return false
+	}
+
+	return true
+}
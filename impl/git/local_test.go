@@ -0,0 +1,163 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/vanroy/microcli/impl/cmd"
+	"github.com/vanroy/microcli/impl/config"
+)
+
+// Create bare repositories under root, each one with an initial commit on main branch
+func newLocalRoot(t *testing.T, repositories ...string) string {
+
+	t.Setenv("GIT_AUTHOR_NAME", "microbox")
+	t.Setenv("GIT_AUTHOR_EMAIL", "microbox@localhost")
+	t.Setenv("GIT_COMMITTER_NAME", "microbox")
+	t.Setenv("GIT_COMMITTER_EMAIL", "microbox@localhost")
+
+	root := t.TempDir()
+	work := t.TempDir()
+
+	for _, repository := range repositories {
+		bare := filepath.Join(root, repository+".git")
+		seed := filepath.Join(work, repository)
+
+		steps := [][]string{
+			{"init", "-q", "--bare", "--initial-branch=main", bare},
+			{"init", "-q", "--initial-branch=main", seed},
+			{"-C", seed, "commit", "-q", "--allow-empty", "-m", "Initial commit"},
+			{"-C", seed, "push", "-q", bare, "main"},
+		}
+
+		for _, step := range steps {
+			if out, err := cmd.ExecCmd("git", step); err != nil {
+				t.Fatalf("Cannot create repository %s : %s", repository, out)
+			}
+		}
+	}
+
+	return root
+}
+
+func TestLocalGetRepositories(t *testing.T) {
+
+	root := newLocalRoot(t, "platform/api", "platform/worker", "tools/cli")
+
+	remote := newLocal(config.Config{Git: config.GitConfig{BaseUrl: "file://" + root, GroupIds: []string{"platform"}}})
+
+	groups, err := remote.getGroups()
+	if err != nil {
+		t.Fatalf("Cannot retrieve groups : %s", err.Error())
+	}
+	if len(groups) != 2 {
+		t.Errorf("Unexpected groups %+v", groups)
+	}
+
+	repos, err := remote.getRepositories()
+	if err != nil {
+		t.Fatalf("Cannot retrieve repositories : %s", err.Error())
+	}
+
+	names := []string{}
+	for _, repo := range repos {
+		names = append(names, repo.Name)
+		if repo.DefaultBranch != "main" {
+			t.Errorf("Unexpected default branch '%s' for %s", repo.DefaultBranch, repo.Name)
+		}
+	}
+
+	if !reflect.DeepEqual(names, []string{"api", "worker"}) {
+		t.Errorf("Unexpected repositories %v", names)
+	}
+}
+
+func TestCloneFromLocal(t *testing.T) {
+
+	root := newLocalRoot(t, "platform/api", "platform/worker")
+
+	workspace := t.TempDir()
+	t.Chdir(workspace)
+
+	commands := NewCommands(config.Config{Git: config.GitConfig{Type: "local", BaseUrl: root, GroupIds: []string{"platform"}, CloneProtocol: "ssh"}})
+
+	if _, err := commands.cloneRepos("a*", nil, false, 2); err != nil {
+		t.Fatalf("Cannot clone repositories : %s", err.Error())
+	}
+
+	if _, err := os.Stat(filepath.Join(workspace, "worker")); !os.IsNotExist(err) {
+		t.Errorf("Repository 'worker' should not be cloned")
+	}
+
+	folders, err := commands.getGitFolders("", nil)
+	if err != nil {
+		t.Fatalf("Cannot list local repositories : %s", err.Error())
+	}
+
+	if !reflect.DeepEqual(folders, []string{"api"}) {
+		t.Errorf("Unexpected local repositories %v", folders)
+	}
+
+	if branch := getBranch("api"); branch != "main" {
+		t.Errorf("Unexpected branch '%s'", branch)
+	}
+}
+
+func TestCloneFromLocalWithNamespaceLayout(t *testing.T) {
+
+	root := newLocalRoot(t, "platform/api", "platform/worker")
+
+	workspace := t.TempDir()
+	t.Chdir(workspace)
+
+	commands := NewCommands(config.Config{Git: config.GitConfig{Type: "local", BaseUrl: root, GroupIds: []string{"platform"}, CloneProtocol: "ssh", Layout: LAYOUT_NAMESPACE}})
+
+	if _, err := commands.cloneRepos("platform/*", nil, false, 2); err != nil {
+		t.Fatalf("Cannot clone repositories : %s", err.Error())
+	}
+
+	folders, err := commands.getGitFolders("", nil)
+	if err != nil {
+		t.Fatalf("Cannot list local repositories : %s", err.Error())
+	}
+
+	if !reflect.DeepEqual(folders, []string{"platform/api", "platform/worker"}) {
+		t.Errorf("Unexpected local repositories %v", folders)
+	}
+}
+
+func TestCloneFromMultipleRemotes(t *testing.T) {
+
+	services := newLocalRoot(t, "services/api")
+	libraries := newLocalRoot(t, "libraries/common")
+
+	workspace := t.TempDir()
+	t.Chdir(workspace)
+
+	commands := NewCommands(config.Config{
+		Git: config.GitConfig{Type: "local", BaseUrl: services, GroupIds: []string{"services"}, CloneProtocol: "ssh"},
+		Remotes: map[string]*config.GitConfig{
+			"oss": {Type: "local", BaseUrl: libraries, GroupIds: []string{"libraries"}, CloneProtocol: "ssh"},
+		},
+	})
+
+	repos, err := commands.cloneRepos("", nil, false, 2)
+	if err != nil {
+		t.Fatalf("Cannot clone repositories : %s", err.Error())
+	}
+
+	remotes := map[string]string{}
+	for _, repo := range repos {
+		remotes[repo.Name] = repo.Remote
+	}
+
+	if !reflect.DeepEqual(remotes, map[string]string{"api": config.DEFAULT_REMOTE, "common": "oss"}) {
+		t.Errorf("Unexpected repository remotes %v", remotes)
+	}
+
+	if r := commands.folderRemote("common"); r.name != "oss" {
+		t.Errorf("Unexpected remote '%s' for local folder", r.name)
+	}
+}
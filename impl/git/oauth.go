@@ -0,0 +1,206 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/vanroy/microcli/impl/cmd"
+	"github.com/vanroy/microcli/impl/config"
+	"github.com/vanroy/microcli/impl/prompt"
+	"github.com/vanroy/microcli/impl/secret"
+)
+
+const (
+	OAUTH_AUTH_MODE         = "oauth"
+	OAUTH_DEVICE_GRANT_TYPE = "urn:ietf:params:oauth:grant-type:device_code"
+)
+
+var oauthAuthMode = prompt.Option{Id: OAUTH_AUTH_MODE, Name: "OAuth device login"}
+
+// Polling interval of device login when not given by provider ( RFC 8628 )
+var oauthDefaultInterval = 5 * time.Second
+
+// OAuth 2.0 device authorization grant endpoints of a provider
+type oauthFlow struct {
+	deviceCodeUrl string
+	tokenUrl      string
+	scopes        string
+}
+
+// Implemented by remotes supporting OAuth device login
+type oauthRemote interface {
+	getOAuthFlow() *oauthFlow
+}
+
+type oauthDeviceCode struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationUri         string `json:"verification_uri"`
+	VerificationUriComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+type oauthToken struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Run device login, user is asked to enter displayed code on verification page
+func (f *oauthFlow) deviceLogin(clientId string) (oauthToken, error) {
+
+	if clientId == "" {
+		return oauthToken{}, errors.New("OAuth client id is required ( OAuthClientId )")
+	}
+
+	resp, err := resty.New().R().
+		SetHeader("Accept", "application/json").
+		SetFormData(map[string]string{"client_id": clientId, "scope": f.scopes}).
+		SetResult(&oauthDeviceCode{}).
+		Post(f.deviceCodeUrl)
+
+	if err != nil {
+		return oauthToken{}, err
+	}
+	if resp.StatusCode() < 200 || resp.StatusCode() > 300 {
+		return oauthToken{}, fmt.Errorf("cannot request device code. Status code : %d. Message : %s", resp.StatusCode(), resp.String())
+	}
+
+	deviceCode := resp.Result().(*oauthDeviceCode)
+
+	verificationUri := deviceCode.VerificationUri
+	if deviceCode.VerificationUriComplete != "" {
+		verificationUri = deviceCode.VerificationUriComplete
+	}
+	prompt.PrintInfo("Open %s%s%s and enter code %s%s", prompt.Color(prompt.FgBlue), verificationUri, prompt.Color(prompt.Reset), prompt.Color(prompt.FgGreen), deviceCode.UserCode)
+
+	interval := time.Duration(deviceCode.Interval) * time.Second
+	if interval <= 0 {
+		interval = oauthDefaultInterval
+	}
+	deadline := time.Now().Add(time.Duration(deviceCode.ExpiresIn) * time.Second)
+
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+
+		token, err := f.requestToken(map[string]string{
+			"client_id":   clientId,
+			"device_code": deviceCode.DeviceCode,
+			"grant_type":  OAUTH_DEVICE_GRANT_TYPE,
+		})
+		if err != nil {
+			return oauthToken{}, err
+		}
+
+		switch token.Error {
+		case "":
+			return token, nil
+		case "authorization_pending":
+			continue
+		case "slow_down":
+			interval += 5 * time.Second
+		default:
+			return oauthToken{}, fmt.Errorf("device login failed ( %s %s )", token.Error, token.ErrorDescription)
+		}
+	}
+
+	return oauthToken{}, errors.New("device code expired")
+}
+
+// Exchange refresh token of remote for a new access token, new tokens are saved in secret store
+func (f *oauthFlow) refresh(conf config.GitConfig) (oauthToken, error) {
+
+	refreshToken, err := config.GetSecret(conf, config.REFRESH_TOKEN_SECRET)
+	if err != nil {
+		return oauthToken{}, err
+	}
+
+	token, err := f.requestToken(map[string]string{
+		"client_id":     conf.OAuthClientId,
+		"refresh_token": refreshToken,
+		"grant_type":    "refresh_token",
+	})
+	if err != nil {
+		return oauthToken{}, err
+	}
+	if token.Error != "" || token.AccessToken == "" {
+		return oauthToken{}, fmt.Errorf("cannot refresh token ( %s %s )", token.Error, token.ErrorDescription)
+	}
+
+	return token, saveOAuthToken(conf, token)
+}
+
+// Request token endpoint, OAuth errors are returned in token
+func (f *oauthFlow) requestToken(data map[string]string) (oauthToken, error) {
+
+	resp, err := resty.New().R().
+		SetHeader("Accept", "application/json").
+		SetFormData(data).
+		SetResult(&oauthToken{}).
+		SetError(&oauthToken{}).
+		Post(f.tokenUrl)
+
+	if err != nil {
+		return oauthToken{}, err
+	}
+
+	if resp.IsError() {
+		if token, ok := resp.Error().(*oauthToken); ok && token.Error != "" {
+			return *token, nil
+		}
+		return oauthToken{}, fmt.Errorf("cannot request token. Status code : %d. Message : %s", resp.StatusCode(), resp.String())
+	}
+
+	return *resp.Result().(*oauthToken), nil
+}
+
+// Save access and refresh tokens of remote in its secret store
+func saveOAuthToken(conf config.GitConfig, token oauthToken) error {
+
+	conf.PrivateToken = token.AccessToken
+	if err := config.SavePassword(conf); err != nil && !errors.Is(err, secret.ErrReadOnly) {
+		return err
+	}
+
+	if token.RefreshToken == "" {
+		return nil
+	}
+
+	if err := config.SaveSecret(conf, config.REFRESH_TOKEN_SECRET, token.RefreshToken); err != nil && !errors.Is(err, secret.ErrReadOnly) {
+		return err
+	}
+
+	return nil
+}
+
+// Return HTTP client, refreshing OAuth token of remote once when a request is rejected with 401
+func newAuthClient(conf *config.GitConfig, flow *oauthFlow, authorize func(r *resty.Request, token string)) *resty.Client {
+
+	client := resty.New()
+	if conf.AuthMode != OAUTH_AUTH_MODE {
+		return client
+	}
+
+	return client.
+		SetRetryCount(1).
+		AddRetryCondition(func(r *resty.Response, err error) bool {
+			return err == nil && r.StatusCode() == http.StatusUnauthorized
+		}).
+		AddRetryHook(func(r *resty.Response, err error) {
+			token, refreshErr := flow.refresh(*conf)
+			if refreshErr != nil {
+				prompt.PrintWarn("Cannot refresh OAuth token, run 'mbx authenticate --device' ( %s )", refreshErr.Error())
+				return
+			}
+
+			cmd.AddSecret(token.AccessToken)
+			conf.PrivateToken = token.AccessToken
+			authorize(r.Request, token.AccessToken)
+		})
+}
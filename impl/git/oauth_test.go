@@ -0,0 +1,105 @@
+package git
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vanroy/microcli/impl/config"
+	"github.com/vanroy/microcli/impl/secret"
+)
+
+// Fake GitHub serving device login, token refresh and an API endpoint only accepting refreshed token
+func newFakeGitHubOAuth(t *testing.T) *httptest.Server {
+
+	polls := 0
+	mux := http.NewServeMux()
+
+	writeJson := func(w http.ResponseWriter, status int, value interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(value) //nolint:errcheck
+	}
+
+	mux.HandleFunc("/login/device/code", func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, http.StatusOK, oauthDeviceCode{DeviceCode: "device", UserCode: "ABCD-1234", VerificationUri: "https://github.com/login/device", ExpiresIn: 60})
+	})
+
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm() //nolint:errcheck
+		switch {
+		case r.Form.Get("grant_type") == OAUTH_DEVICE_GRANT_TYPE && polls == 0:
+			polls++
+			writeJson(w, http.StatusOK, oauthToken{Error: "authorization_pending"})
+		case r.Form.Get("grant_type") == OAUTH_DEVICE_GRANT_TYPE:
+			writeJson(w, http.StatusOK, oauthToken{AccessToken: "access-1", RefreshToken: "refresh-1"})
+		case r.Form.Get("refresh_token") == "refresh-1":
+			writeJson(w, http.StatusOK, oauthToken{AccessToken: "access-2", RefreshToken: "refresh-2"})
+		default:
+			writeJson(w, http.StatusBadRequest, oauthToken{Error: "invalid_grant"})
+		}
+	})
+
+	mux.HandleFunc("/api/v3/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-2" {
+			writeJson(w, http.StatusUnauthorized, map[string]string{"message": "Bad credentials"})
+			return
+		}
+		writeJson(w, http.StatusOK, ghOrg{Login: "john"})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestOAuthDeviceLogin(t *testing.T) {
+
+	// Fake server does not give polling interval
+	defaultInterval := oauthDefaultInterval
+	oauthDefaultInterval = 10 * time.Millisecond
+	t.Cleanup(func() { oauthDefaultInterval = defaultInterval })
+
+	server := newFakeGitHubOAuth(t)
+	gh := newGitHub(config.Config{Git: config.GitConfig{BaseUrl: server.URL, AuthMode: OAUTH_AUTH_MODE}}).(*gitHub)
+
+	token, err := gh.getOAuthFlow().deviceLogin("client")
+	if err != nil {
+		t.Fatalf("Cannot login : %s", err.Error())
+	}
+
+	if token.AccessToken != "access-1" || token.RefreshToken != "refresh-1" {
+		t.Errorf("Unexpected token %+v", token)
+	}
+}
+
+func TestOAuthRefreshOnUnauthorized(t *testing.T) {
+
+	server := newFakeGitHubOAuth(t)
+	t.Chdir(t.TempDir())
+
+	// Refresh token is read from a read-only store, new tokens are not saved
+	gh := newGitHub(config.Config{Git: config.GitConfig{
+		BaseUrl:       server.URL,
+		AuthMode:      OAUTH_AUTH_MODE,
+		OAuthClientId: "client",
+		PrivateToken:  "expired",
+		SecretStore:   secret.COMMAND,
+		SecretCommand: "echo refresh-1",
+	}}).(*gitHub)
+
+	user, _, err := gh.execGet("user", &ghOrg{})
+	if err != nil {
+		t.Fatalf("Cannot execute request : %s", err.Error())
+	}
+
+	if user.(*ghOrg).Login != "john" {
+		t.Errorf("Request must be retried with refreshed token, got %+v", user)
+	}
+	if gh.config.Git.PrivateToken != "access-2" {
+		t.Errorf("Refreshed token must be kept for next requests, got '%s'", gh.config.Git.PrivateToken)
+	}
+}
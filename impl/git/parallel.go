@@ -0,0 +1,106 @@
+package git
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/urfave/cli/v3"
+	"github.com/vanroy/microcli/impl/prompt"
+)
+
+type jobResult struct {
+	Name   string
+	Err    error
+	output bytes.Buffer
+}
+
+// Task executed for one repository, messages must be written on the given printer
+type jobTask func(out *prompt.Printer, item string) error
+
+// Run task for each item with a bounded pool of workers, output of each task is printed in items order
+func runJobs(jobs int, items []string, task jobTask) []*jobResult {
+
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	results := make([]*jobResult, len(items))
+	done := make([]chan struct{}, len(items))
+	for i := range items {
+		results[i] = &jobResult{Name: items[i]}
+		done[i] = make(chan struct{})
+	}
+
+	queue := make(chan int)
+	var workers sync.WaitGroup
+
+	for w := 0; w < jobs && w < len(items); w++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for i := range queue {
+				results[i].Err = task(prompt.NewPrinter(&results[i].output), items[i])
+				close(done[i])
+			}
+		}()
+	}
+
+	go func() {
+		for i := range items {
+			queue <- i
+		}
+		close(queue)
+	}()
+
+	// Flush output as soon as all previous tasks are completed to keep ordering
+	for i := range items {
+		<-done[i]
+		results[i].output.WriteTo(os.Stdout) //nolint:errcheck
+	}
+
+	workers.Wait()
+
+	return results
+}
+
+// Display count of succeeded / failed tasks
+func printJobsSummary(results []*jobResult) {
+
+	var failed []*jobResult
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+
+	if len(results) == 0 {
+		return
+	}
+
+	prompt.PrintNewLine()
+	if len(failed) == 0 {
+		prompt.PrintInfo("%d %ssucceeded", len(results), prompt.Color(prompt.FgGreen))
+		return
+	}
+
+	prompt.PrintInfo("%d %ssucceeded%s, %d %sfailed", len(results)-len(failed), prompt.Color(prompt.FgGreen), prompt.Color(prompt.FgWhite), len(failed), prompt.Color(prompt.FgRed))
+	for _, result := range failed {
+		prompt.PrintItem(result.Name + " ( " + result.Err.Error() + " )")
+	}
+}
+
+// Return number of parallel jobs, from command flag or config, default to number of CPU
+func (g *GitCommands) jobs(c *cli.Command) int {
+
+	if c != nil && c.IsSet("jobs") {
+		return c.Int("jobs")
+	}
+
+	if g.config.Git.Jobs > 0 {
+		return g.config.Git.Jobs
+	}
+
+	return runtime.NumCPU()
+}
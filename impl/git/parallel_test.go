@@ -0,0 +1,66 @@
+package git
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vanroy/microcli/impl/prompt"
+)
+
+func TestRunJobs(t *testing.T) {
+
+	stdout := os.Stdout
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = writer
+	t.Cleanup(func() { os.Stdout = stdout })
+
+	var running, maxRunning int32
+	items := []string{"a", "b", "c", "d", "e"}
+
+	results := runJobs(2, items, func(out *prompt.Printer, item string) error {
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			observed := atomic.LoadInt32(&maxRunning)
+			if current <= observed || atomic.CompareAndSwapInt32(&maxRunning, observed, current) {
+				break
+			}
+		}
+
+		// First items are the slowest, output must still be printed in items order
+		time.Sleep(time.Duration(len(items)-int(item[0]-'a')) * 5 * time.Millisecond)
+		out.PrintItem(item)
+
+		if item == "b" || item == "d" {
+			return errors.New("failed " + item)
+		}
+		return nil
+	})
+
+	writer.Close() //nolint:errcheck
+	printed, _ := io.ReadAll(reader)
+
+	if maxRunning > 2 {
+		t.Errorf("expected at most 2 parallel jobs, got %d", maxRunning)
+	}
+
+	if string(printed) != "* a \n* b \n* c \n* d \n* e \n" {
+		t.Errorf("unexpected output order %q", printed)
+	}
+
+	for i, result := range results {
+		if result.Name != items[i] {
+			t.Errorf("unexpected result %d : %s", i, result.Name)
+		}
+		if failed := result.Err != nil; failed != (result.Name == "b" || result.Name == "d") {
+			t.Errorf("unexpected error for %s : %v", result.Name, result.Err)
+		}
+	}
+}
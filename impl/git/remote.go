@@ -0,0 +1,173 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/thoas/go-funk"
+	"github.com/urfave/cli/v3"
+	"github.com/vanroy/microcli/impl/config"
+	"github.com/vanroy/microcli/impl/prompt"
+)
+
+// Labels used when workspace mix remotes of different types
+var genericLabels = Labels{
+	GroupLabel:            "group",
+	GroupsLabel:           "groups",
+	RepositoryLabel:       "repository",
+	RepositoriesLabel:     "repositories",
+	CreateGroupUsage:      "name [...]",
+	CreateRepositoryUsage: "[group] name [...]",
+	CodeReviewRequest:     "review request",
+}
+
+// Named remote hosting of workspace
+type remote struct {
+	name   string
+	config config.Config
+	impl   gitRemote
+}
+
+// Build remotes of workspace, the default one ( Git section ) first
+func newRemotes(conf config.Config) []*remote {
+
+	return funk.Map(conf.RemoteNames(), func(name string) *remote {
+		remoteConf := conf.ForRemote(name)
+		return &remote{name: name, config: remoteConf, impl: getImpl(remoteConf)}
+	}).([]*remote)
+}
+
+// Return remote by name, nil if unknown
+func (g *GitCommands) remote(name string) *remote {
+
+	for _, r := range g.remotes {
+		if r.name == name {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// Return remote selected with --remote flag, default to first one
+func (g *GitCommands) selectRemote(c *cli.Command) (*remote, error) {
+
+	name := ""
+	if c != nil {
+		name = c.String("remote")
+	}
+
+	if name == "" {
+		return g.remotes[0], nil
+	}
+
+	if r := g.remote(name); r != nil {
+		return r, nil
+	}
+
+	names := funk.Map(g.remotes, func(r *remote) string { return r.name }).([]string)
+	prompt.PrintErrorf("Unknown remote '%s', expected one of %s", name, strings.Join(names, ", "))
+	return nil, fmt.Errorf("unknown remote '%s'", name)
+}
+
+// Return repositories of all remotes, tagged with their remote name
+func (g *GitCommands) getRepositories() ([]gitRepository, error) {
+
+	var repos []gitRepository
+	for _, r := range g.remotes {
+		remoteRepos, err := r.impl.getRepositories()
+		if err != nil {
+			if len(g.remotes) > 1 {
+				return nil, fmt.Errorf("%s ( remote '%s' )", err.Error(), r.name)
+			}
+			return nil, err
+		}
+
+		for _, repo := range remoteRepos {
+			repo.Remote = r.name
+			repos = append(repos, repo)
+		}
+	}
+
+	return repos, nil
+}
+
+// Return remote owning repository
+func (g *GitCommands) repoRemote(repo gitRepository) (*remote, error) {
+
+	if r := g.remote(repo.Remote); r != nil {
+		return r, nil
+	}
+
+	if len(g.remotes) == 1 {
+		return g.remotes[0], nil
+	}
+
+	return nil, errors.New("unknown remote of " + repo.Name)
+}
+
+// Return remote matching with origin URL of local folder, default to first one
+func (g *GitCommands) folderRemote(folder string) *remote {
+
+	if len(g.remotes) == 1 {
+		return g.remotes[0]
+	}
+
+	if r := g.urlRemote(getRepoUrl(folder)); r != nil {
+		return r
+	}
+
+	return g.remotes[0]
+}
+
+// Return remote whose base URL host match with repository URL, nil if none
+func (g *GitCommands) urlRemote(repoUrl string) *remote {
+
+	if repoUrl == "" {
+		return nil
+	}
+
+	for _, r := range g.remotes {
+		if r.config.Git.Type == "local" {
+			rootPath := r.impl.(*local).rootPath
+			if strings.HasPrefix(filepath.Clean(strings.TrimPrefix(repoUrl, "file://")), rootPath+string(filepath.Separator)) {
+				return r
+			}
+			continue
+		}
+
+		if host := urlHost(r.config.Git.BaseUrl); host != "" && host == urlHost(repoUrl) {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// Return host of HTTP / SSH URL, without API / SSH sub domain ( ex: api.github.com -> github.com )
+func urlHost(rawUrl string) string {
+
+	// SCP like syntax ( ex: git@github.com:org/repo.git )
+	if !strings.Contains(rawUrl, "://") {
+		if at := strings.Index(rawUrl, "@"); at >= 0 {
+			rawUrl = "ssh://" + strings.Replace(rawUrl[at+1:], ":", "/", 1)
+		} else {
+			rawUrl = "https://" + rawUrl
+		}
+	}
+
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return ""
+	}
+
+	host := strings.ToLower(parsedUrl.Hostname())
+	for _, prefix := range []string{"api.", "ssh."} {
+		host = strings.TrimPrefix(host, prefix)
+	}
+
+	return host
+}
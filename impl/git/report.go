@@ -0,0 +1,229 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/thoas/go-funk"
+	"github.com/vanroy/microcli/impl/cmd"
+	"github.com/vanroy/microcli/impl/file"
+)
+
+const (
+	SYNC_CLEAN            = "clean"
+	SYNC_LOCAL_ONLY       = "local-only"
+	SYNC_NO_REMOTE_BRANCH = "no-remote-branch"
+	SYNC_AHEAD            = "ahead"
+	SYNC_BEHIND           = "behind"
+	SYNC_DIVERGED         = "diverged"
+	SYNC_DETACHED         = "detached"
+	SYNC_ERROR            = "error"
+
+	DIRTY_UNCACHED    = "uncached"
+	DIRTY_UNCOMMITTED = "uncommitted"
+	DIRTY_UNTRACKED   = "untracked"
+
+	FILTER_DIRTY = "dirty"
+)
+
+var statusFilters = []string{FILTER_DIRTY, SYNC_BEHIND, SYNC_AHEAD, SYNC_DIVERGED}
+
+// Marker files of operations in progress, in GIT directory
+var inProgressOperations = []struct {
+	Name   string
+	Marker string
+}{
+	{Name: "rebase", Marker: "rebase-merge"},
+	{Name: "rebase", Marker: "rebase-apply"},
+	{Name: "merge", Marker: "MERGE_HEAD"},
+	{Name: "cherry-pick", Marker: "CHERRY_PICK_HEAD"},
+	{Name: "revert", Marker: "REVERT_HEAD"},
+	{Name: "bisect", Marker: "BISECT_LOG"},
+}
+
+// Machine-readable representation of a repository ( remote and / or local )
+type repoRecord struct {
+	Name          string `json:"name" yaml:"name"`
+	Path          string `json:"path" yaml:"path"`
+	Namespace     string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Remote        string `json:"remote,omitempty" yaml:"remote,omitempty"`
+	Description   string `json:"description,omitempty" yaml:"description,omitempty"`
+	DefaultBranch string `json:"defaultBranch,omitempty" yaml:"defaultBranch,omitempty"`
+	CurrentBranch string `json:"currentBranch,omitempty" yaml:"currentBranch,omitempty"`
+	Dirty         bool   `json:"dirty" yaml:"dirty"`
+	DirtyReason   string `json:"dirtyReason,omitempty" yaml:"dirtyReason,omitempty"`
+	Sync          string `json:"sync,omitempty" yaml:"sync,omitempty"`
+	Ahead         int    `json:"ahead" yaml:"ahead"`
+	Behind        int    `json:"behind" yaml:"behind"`
+	Detached      bool   `json:"detached" yaml:"detached"`
+	Operation     string `json:"operation,omitempty" yaml:"operation,omitempty"`
+	Stashes       int    `json:"stashes" yaml:"stashes"`
+	Archived      bool   `json:"archived" yaml:"archived"`
+	OriginUrl     string `json:"originUrl,omitempty" yaml:"originUrl,omitempty"`
+	SshUrl        string `json:"sshUrl,omitempty" yaml:"sshUrl,omitempty"`
+	HttpUrl       string `json:"httpUrl,omitempty" yaml:"httpUrl,omitempty"`
+}
+
+// Build record from remote repository, default branch not returned by listing is resolved with one request per repository
+func (g *GitCommands) toRecord(repo gitRepository) repoRecord {
+
+	return repoRecord{
+		Name:          repo.Name,
+		Path:          g.localPath(repo),
+		Namespace:     repo.NameWithNamespace,
+		Remote:        repo.Remote,
+		Description:   strings.TrimSpace(repo.Description),
+		DefaultBranch: g.remoteDefaultBranch(repo),
+		Archived:      repo.Archived,
+		SshUrl:        repo.SshUrl,
+		HttpUrl:       repo.HttpUrl,
+	}
+}
+
+// Build record from local repository, sync state is only computed if fetch is enabled
+func (g *GitCommands) toLocalRecord(folder string, fetch bool) repoRecord {
+
+	record := repoRecord{
+		Name:          filepath.Base(folder),
+		Path:          folder,
+		CurrentBranch: getBranch(folder),
+		OriginUrl:     getRepoUrl(folder),
+	}
+
+	if !checkUncachedUncommitted(folder) {
+		record.DirtyReason = DIRTY_UNCACHED
+	} else if !checkCachedUncommitted(folder) {
+		record.DirtyReason = DIRTY_UNCOMMITTED
+	} else if !checkUntracked(folder) {
+		record.DirtyReason = DIRTY_UNTRACKED
+	}
+	if r := g.urlRemote(record.OriginUrl); r != nil {
+		record.Remote = r.name
+	}
+
+	record.Dirty = record.DirtyReason != ""
+	record.Detached = checkDetached(folder)
+	record.Operation = getOperationInProgress(folder)
+	record.Stashes = countStashes(folder)
+
+	if !fetch {
+		return record
+	}
+
+	if record.OriginUrl == "" {
+		record.Sync = SYNC_LOCAL_ONLY
+		return record
+	}
+
+	if record.Detached {
+		record.Sync = SYNC_DETACHED
+		return record
+	}
+
+	// Fetch from remote
+	g.fetch(folder)
+
+	// Check for diverged branches
+	local, remote := checkBranchOrigin(folder, record.CurrentBranch)
+
+	if remote == "" {
+		record.Sync = SYNC_NO_REMOTE_BRANCH
+	} else if local == "" {
+		record.Sync = SYNC_ERROR
+	} else if local != remote {
+		var err error
+		record.Ahead, record.Behind, err = countAheadBehind(folder, record.CurrentBranch)
+		switch {
+		case err != nil:
+			record.Sync = SYNC_ERROR
+		case record.Ahead > 0 && record.Behind > 0:
+			record.Sync = SYNC_DIVERGED
+		case record.Ahead > 0:
+			record.Sync = SYNC_AHEAD
+		default:
+			record.Sync = SYNC_BEHIND
+		}
+	} else {
+		record.Sync = SYNC_CLEAN
+	}
+
+	return record
+}
+
+// Return true if record match with filters, sync filters ( ahead / behind / diverged ) are combined with OR, dirty filter with AND
+func (r repoRecord) matchFilters(filters []string) bool {
+
+	if funk.ContainsString(filters, FILTER_DIRTY) && !r.Dirty {
+		return false
+	}
+
+	syncFilters := funk.FilterString(filters, func(filter string) bool { return filter != FILTER_DIRTY })
+
+	return len(syncFilters) == 0 || funk.ContainsString(syncFilters, r.Sync)
+}
+
+// Return true if HEAD does not point to a branch
+func checkDetached(folder string) bool {
+	_, err := cmd.ExecCmd("git", []string{"-C", file.Rel(folder), "symbolic-ref", "-q", "HEAD"})
+	return err != nil
+}
+
+// Return name of operation in progress ( rebase, merge, cherry-pick ... ), empty if none
+func getOperationInProgress(folder string) string {
+
+	gitDir, err := cmd.ExecCmd("git", []string{"-C", file.Rel(folder), "rev-parse", "--git-dir"})
+	if err != nil {
+		return ""
+	}
+
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(file.Rel(folder), gitDir)
+	}
+
+	for _, operation := range inProgressOperations {
+		if _, err := os.Stat(filepath.Join(gitDir, operation.Marker)); err == nil {
+			return operation.Name
+		}
+	}
+
+	return ""
+}
+
+// Return number of stash entries
+func countStashes(folder string) int {
+
+	out, err := cmd.ExecCmd("git", []string{"-C", file.Rel(folder), "stash", "list"})
+	if err != nil || strings.TrimSpace(out) == "" {
+		return 0
+	}
+
+	return len(strings.Split(strings.TrimSpace(out), "\n"))
+}
+
+// Return number of commits ahead and behind the remote branch
+func countAheadBehind(folder string, branch string) (int, int, error) {
+
+	out, err := cmd.ExecCmd("git", []string{"-C", file.Rel(folder), "rev-list", "--left-right", "--count", branch + "...origin/" + branch})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	counts := strings.Fields(out)
+	if len(counts) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rev-list output '%s'", out)
+	}
+
+	ahead, err := strconv.Atoi(counts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	behind, err := strconv.Atoi(counts[1])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return ahead, behind, nil
+}
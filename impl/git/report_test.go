@@ -0,0 +1,147 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thoas/go-funk"
+	"github.com/urfave/cli/v3"
+	"github.com/vanroy/microcli/impl/cmd"
+	"github.com/vanroy/microcli/impl/config"
+	"github.com/vanroy/microcli/impl/output"
+	"gopkg.in/yaml.v3"
+)
+
+// Return what is printed on standard output by run
+func captureStdout(t *testing.T, run func() error) string {
+
+	stdout := os.Stdout
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		out <- string(data)
+	}()
+
+	runErr := run()
+	writer.Close() //nolint:errcheck
+	if runErr != nil {
+		t.Fatal(runErr)
+	}
+	return <-out
+}
+
+func TestRepoRecordOutput(t *testing.T) {
+
+	root := newLocalRoot(t, "platform/api", "platform/web")
+
+	workspace := t.TempDir()
+	t.Chdir(workspace)
+
+	commands := NewCommands(config.Config{Git: config.GitConfig{Type: "local", BaseUrl: root, GroupIds: []string{"platform"}, CloneProtocol: "ssh"}})
+	if _, err := commands.cloneRepos("", nil, false, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// 'api' has staged changes, 'web' has a commit not pushed
+	os.WriteFile(filepath.Join(workspace, "api", "README"), []byte("hello"), 0644) //nolint:errcheck
+	steps := [][]string{
+		{"-C", "api", "add", "README"},
+		{"-C", "web", "commit", "-q", "--allow-empty", "-m", "Local commit"},
+	}
+	for _, step := range steps {
+		if out, err := cmd.ExecCmd("git", step); err != nil {
+			t.Fatalf("Cannot prepare repositories : %s", out)
+		}
+	}
+
+	t.Cleanup(func() { config.Options.Output = output.TABLE })
+
+	byName := func(records []repoRecord) map[string]repoRecord {
+		return funk.ToMap(records, "Name").(map[string]repoRecord)
+	}
+
+	config.Options.Output = output.JSON
+	var local []repoRecord
+	listOut := captureStdout(t, func() error {
+		return (&cli.Command{Name: "list", Action: commands.ListLocal}).Run(context.Background(), []string{"list"})
+	})
+	if err := json.Unmarshal([]byte(listOut), &local); err != nil {
+		t.Fatal(err)
+	}
+	if api := byName(local)["api"]; len(local) != 2 || !api.Dirty || api.DirtyReason != DIRTY_UNCOMMITTED || api.CurrentBranch != "main" || api.Remote != "default" || api.Sync != "" {
+		t.Errorf("unexpected list records %+v", local)
+	}
+
+	config.Options.Output = output.YAML
+	var remote []repoRecord
+	glistOut := captureStdout(t, func() error {
+		return (&cli.Command{Name: "glist", Action: commands.ListRemote}).Run(context.Background(), []string{"glist"})
+	})
+	if err := yaml.Unmarshal([]byte(glistOut), &remote); err != nil {
+		t.Fatal(err)
+	}
+	if web := byName(remote)["web"]; len(remote) != 2 || web.Path != "web" || web.Namespace != "platform / web" || web.DefaultBranch != "main" || web.Dirty {
+		t.Errorf("unexpected glist records %+v", remote)
+	}
+
+	config.Options.Output = output.JSON
+	var status []repoRecord
+	gstOut := captureStdout(t, func() error {
+		return (&cli.Command{Name: "gst", Flags: []cli.Flag{&cli.StringSliceFlag{Name: "only"}}, Action: commands.St}).Run(context.Background(), []string{"gst"})
+	})
+	// Status lines must not be mixed with structured output
+	if err := json.Unmarshal([]byte(gstOut), &status); err != nil {
+		t.Fatalf("%s :\n%s", err.Error(), gstOut)
+	}
+	records := byName(status)
+	if api := records["api"]; !api.Dirty || api.DirtyReason != DIRTY_UNCOMMITTED || api.Sync != SYNC_CLEAN {
+		t.Errorf("unexpected gst record %+v", api)
+	}
+	if web := records["web"]; web.Dirty || web.Sync != SYNC_AHEAD || web.Ahead != 1 || web.Behind != 0 {
+		t.Errorf("unexpected gst record %+v", web)
+	}
+}
+
+func TestRepoRecordMatchFilters(t *testing.T) {
+
+	tests := []struct {
+		record   repoRecord
+		filters  []string
+		expected bool
+	}{
+		{repoRecord{Sync: SYNC_CLEAN}, nil, true},
+		{repoRecord{Sync: SYNC_AHEAD}, []string{SYNC_AHEAD, SYNC_BEHIND}, true},
+		{repoRecord{Sync: SYNC_BEHIND}, []string{SYNC_AHEAD, SYNC_BEHIND}, true},
+		{repoRecord{Sync: SYNC_CLEAN}, []string{SYNC_AHEAD, SYNC_BEHIND}, false},
+		{repoRecord{Sync: SYNC_CLEAN, Dirty: true}, []string{FILTER_DIRTY}, true},
+		{repoRecord{Sync: SYNC_AHEAD}, []string{FILTER_DIRTY, SYNC_AHEAD}, false},
+		{repoRecord{Sync: SYNC_AHEAD, Dirty: true}, []string{FILTER_DIRTY, SYNC_AHEAD, SYNC_DIVERGED}, true},
+		{repoRecord{Sync: SYNC_CLEAN, Dirty: true}, []string{FILTER_DIRTY, SYNC_AHEAD}, false},
+	}
+
+	for _, test := range tests {
+		if match := test.record.matchFilters(test.filters); match != test.expected {
+			t.Errorf("%+v with filters %v : expected %t", test.record, test.filters, test.expected)
+		}
+	}
+}
+
+func TestCountAheadBehindError(t *testing.T) {
+
+	t.Chdir(t.TempDir())
+
+	if _, _, err := countAheadBehind("missing", "main"); err == nil {
+		t.Errorf("expected error when branches cannot be compared")
+	}
+}
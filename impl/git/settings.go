@@ -0,0 +1,284 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/thoas/go-funk"
+	"github.com/urfave/cli/v3"
+	"github.com/vanroy/microcli/impl/cmd"
+	"github.com/vanroy/microcli/impl/config"
+	"github.com/vanroy/microcli/impl/prompt"
+	"github.com/vanroy/microcli/impl/secret"
+)
+
+const (
+	REACHABILITY_TIMEOUT = 10 * time.Second
+)
+
+// Fields holding secrets, in Git section or named remotes
+var secretFields = []string{"PrivateToken"}
+
+// Display value of config key
+func ConfigGet(_ context.Context, c *cli.Command) error {
+
+	conf, err := config.Load()
+	if err != nil && !errors.Is(err, config.ErrSecret) {
+		return err
+	}
+
+	value, err := config.GetValue(*conf, c.Args().Get(0))
+	if err != nil {
+		prompt.PrintErrorf("Cannot get value ( %s )", err.Error())
+		return err
+	}
+
+	fmt.Println(maskSecret(c.Args().Get(0), value))
+
+	return nil
+}
+
+// Set value of config key in workspace config
+func ConfigSet(_ context.Context, c *cli.Command) error {
+
+	if c.NArg() < 2 {
+		prompt.PrintErrorf("Missing parameters, key and value are required")
+		return errors.New("missing parameters")
+	}
+
+	return updateConfig(func(conf *config.Config) error {
+		return config.SetValue(conf, c.Args().Get(0), strings.Join(c.Args().Slice()[1:], " "))
+	})
+}
+
+// Remove config key from workspace config
+func ConfigUnset(_ context.Context, c *cli.Command) error {
+
+	return updateConfig(func(conf *config.Config) error {
+		return config.UnsetValue(conf, c.Args().Get(0))
+	})
+}
+
+// Display all effective config values
+func ConfigList(_ context.Context, c *cli.Command) error {
+
+	conf, err := config.Load()
+	if err != nil && !errors.Is(err, config.ErrSecret) {
+		return err
+	}
+
+	for _, key := range append(config.Keys(), config.RemoteKeys(*conf)...) {
+		value, _ := config.GetValue(*conf, key)
+		fmt.Printf("%s = %s\n", key, maskSecret(key, value))
+	}
+
+	return nil
+}
+
+// Edit workspace config with $VISUAL / $EDITOR
+func ConfigEdit(_ context.Context, c *cli.Command) error {
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	editorArgs := strings.Fields(editor)
+	if err := cmd.ExecInteractiveCmd(editorArgs[0], append(editorArgs[1:], config.File())); err != nil {
+		prompt.PrintErrorf("Cannot edit config ( %s )", err.Error())
+		return err
+	}
+
+	return ConfigValidate(context.Background(), c)
+}
+
+// Validate effective config
+func ConfigValidate(_ context.Context, c *cli.Command) error {
+
+	conf, err := config.Load()
+	if err != nil && !errors.Is(err, config.ErrSecret) {
+		prompt.PrintErrorf("Invalid config ( %s )", err.Error())
+		return err
+	}
+
+	problems := validateConfig(*conf, true)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	if len(problems) > 0 {
+		funk.ForEach(problems, func(problem string) { prompt.PrintError(problem) })
+		return errors.New("invalid config")
+	}
+
+	prompt.PrintInfo("Config is %svalid", prompt.Color(prompt.FgGreen))
+	return nil
+}
+
+// Update workspace config, previous config is restored if resulting effective config is invalid
+func updateConfig(update func(conf *config.Config) error) error {
+
+	previous, err := config.LoadWorkspace()
+	if err != nil {
+		return err
+	}
+
+	// Keep original content, config file is removed on rollback if it did not exist
+	configFile := config.File()
+	content, readErr := os.ReadFile(configFile)
+	rollback := func() {
+		if os.IsNotExist(readErr) {
+			os.Remove(configFile) //nolint:errcheck
+		} else if readErr == nil {
+			os.WriteFile(configFile, content, 0644) //nolint:errcheck
+		}
+	}
+
+	updated := previous.Copy()
+	if err := update(&updated); err != nil {
+		prompt.PrintErrorf("Cannot update config ( %s )", err.Error())
+		return err
+	}
+
+	if err := config.Save(updated); err != nil {
+		prompt.PrintErrorf("Cannot save config ( %s )", err.Error())
+		return err
+	}
+
+	// Validate effective config, without network checks ( secret store is not required to update config )
+	effective, err := config.Load()
+	if err != nil && !errors.Is(err, config.ErrSecret) {
+		rollback()
+		prompt.PrintErrorf("Invalid config ( %s )", err.Error())
+		return err
+	}
+
+	problems := validateConfig(*effective, false)
+	if len(problems) > 0 {
+		rollback()
+		funk.ForEach(problems, func(problem string) { prompt.PrintError(problem) })
+		return errors.New("invalid config")
+	}
+
+	prompt.PrintInfo("Config saved")
+
+	return nil
+}
+
+// Return list of config problems, reachability of base URLs is only checked if network is enabled
+func validateConfig(conf config.Config, network bool) []string {
+
+	var problems []string
+
+	for _, name := range conf.RemoteNames() {
+		problems = append(problems, validateRemote(config.RemoteKeyPrefix(name), conf.ForRemote(name).Git, network)...)
+	}
+
+	if _, ok := conf.Remotes[config.DEFAULT_REMOTE]; ok {
+		problems = append(problems, fmt.Sprintf("Remote name '%s' is reserved to Git section", config.DEFAULT_REMOTE))
+	}
+
+	if conf.Git.Layout != "" && conf.Git.Layout != LAYOUT_FLAT && conf.Git.Layout != LAYOUT_NAMESPACE {
+		problems = append(problems, fmt.Sprintf("Git.Layout '%s' is invalid, expected one of %s, %s", conf.Git.Layout, LAYOUT_FLAT, LAYOUT_NAMESPACE))
+	}
+
+	if conf.Git.Jobs < 0 {
+		problems = append(problems, "Git.Jobs must be positive")
+	}
+
+	if conf.Initializr.Url != "" {
+		if _, err := url.ParseRequestURI(conf.Initializr.Url); err != nil {
+			problems = append(problems, fmt.Sprintf("Initializr.Url '%s' is invalid", conf.Initializr.Url))
+		}
+	}
+
+	return problems
+}
+
+// Return list of remote config problems, keys are reported with prefix ( ex: Git. )
+func validateRemote(prefix string, conf config.GitConfig, network bool) []string {
+
+	var problems []string
+
+	implCfg := getImplCfg(conf.Type)
+	if implCfg == nil {
+		types := funk.Map(gitImplements, func(impl GitImplement) string { return impl.Id }).([]string)
+		problems = append(problems, fmt.Sprintf("%sType '%s' is invalid, expected one of %s", prefix, conf.Type, strings.Join(types, ", ")))
+	} else if conf.AuthMode != "" {
+		authModes := funk.Map(implCfg.AuthModes, func(option prompt.Option) string { return option.Id }).([]string)
+		if !funk.ContainsString(authModes, conf.AuthMode) {
+			problems = append(problems, fmt.Sprintf("%sAuthMode '%s' is invalid for %s, expected one of %s", prefix, conf.AuthMode, implCfg.Name, strings.Join(authModes, ", ")))
+		}
+	}
+
+	if conf.AuthMode == OAUTH_AUTH_MODE && conf.OAuthClientId == "" {
+		problems = append(problems, prefix+"OAuthClientId is required by oauth auth mode")
+	}
+
+	if conf.AuthMode == GITHUB_APP_AUTH_MODE {
+		if conf.AppId == "" {
+			problems = append(problems, prefix+"AppId is required by github-app auth mode")
+		}
+		if conf.AppPrivateKeyPath == "" {
+			problems = append(problems, prefix+"AppPrivateKeyPath is required by github-app auth mode")
+		} else if _, err := readPrivateKey((&gitHub{config: config.Config{Git: conf}}).appPrivateKeyPath()); err != nil {
+			problems = append(problems, fmt.Sprintf("%sAppPrivateKeyPath is invalid ( %s )", prefix, err.Error()))
+		}
+	}
+
+	if conf.SecretStore != "" && !funk.ContainsString(secret.Backends, conf.SecretStore) {
+		problems = append(problems, fmt.Sprintf("%sSecretStore '%s' is invalid, expected one of %s", prefix, conf.SecretStore, strings.Join(secret.Backends, ", ")))
+	} else if conf.SecretStore == secret.ENV && conf.SecretEnv == "" {
+		problems = append(problems, prefix+"SecretEnv is required by env secret store")
+	} else if conf.SecretStore == secret.COMMAND && conf.SecretCommand == "" {
+		problems = append(problems, prefix+"SecretCommand is required by command secret store")
+	}
+
+	// mbx credential helper would query GIT credential helper, which can call mbx again
+	if conf.SecretStore == secret.GIT_CREDENTIAL && conf.UseTokenForOperation {
+		problems = append(problems, prefix+"UseTokenForOperation cannot be used with git-credential secret store, GIT credential helper already provides the token")
+	}
+
+	if conf.CloneProtocol != "" && conf.CloneProtocol != "ssh" && conf.CloneProtocol != "https" {
+		problems = append(problems, fmt.Sprintf("%sCloneProtocol '%s' is invalid, expected one of ssh, https", prefix, conf.CloneProtocol))
+	}
+
+	if conf.BaseUrl == "" {
+		problems = append(problems, prefix+"BaseUrl is required")
+	} else if network {
+		if err := checkReachable(conf.Type, conf.BaseUrl); err != nil {
+			problems = append(problems, fmt.Sprintf("%sBaseUrl '%s' is not reachable ( %s )", prefix, conf.BaseUrl, err.Error()))
+		}
+	}
+
+	return problems
+}
+
+// Check base URL respond, any HTTP response is accepted ( local provider check path existence )
+func checkReachable(gitType string, baseUrl string) error {
+
+	if gitType == "local" {
+		_, err := os.Stat(newLocal(config.Config{Git: config.GitConfig{BaseUrl: baseUrl}}).(*local).rootPath)
+		return err
+	}
+
+	_, err := resty.New().SetTimeout(REACHABILITY_TIMEOUT).R().Get(baseUrl)
+	return err
+}
+
+func maskSecret(key string, value string) string {
+	for _, secretField := range secretFields {
+		if value != "" && strings.EqualFold(secretField, key[strings.LastIndex(key, ".")+1:]) {
+			return "********"
+		}
+	}
+	return value
+}
@@ -0,0 +1,163 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/urfave/cli/v3"
+	"github.com/vanroy/microcli/impl/config"
+)
+
+const settingsWorkspaceConfig = `# Team workspace
+[Git]
+Type = "local"
+BaseUrl = "/srv/git"
+AuthMode = "none"
+`
+
+// Create workspace with config content, without user config, and move into it
+func newSettingsWorkspace(t *testing.T, content string) string {
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	workspace := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(workspace, ".microbox"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if content != "" {
+		os.WriteFile(filepath.Join(workspace, config.CONFIG_FILE), []byte(content), 0644) //nolint:errcheck
+	}
+	t.Chdir(workspace)
+
+	return filepath.Join(workspace, config.CONFIG_FILE)
+}
+
+// Return effective config, test fails if it cannot be loaded
+func mustLoad(t *testing.T) *config.Config {
+	conf, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conf
+}
+
+// Run config sub command with arguments
+func runConfig(name string, action cli.ActionFunc, args ...string) error {
+	return (&cli.Command{Name: name, Action: action}).Run(context.Background(), append([]string{name}, args...))
+}
+
+func TestConfigSetGetUnset(t *testing.T) {
+
+	newSettingsWorkspace(t, settingsWorkspaceConfig)
+
+	get := func(key string) string {
+		return captureStdout(t, func() error { return runConfig("get", ConfigGet, key) })
+	}
+
+	if err := runConfig("set", ConfigSet, "git.jobs", "4"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runConfig("set", ConfigSet, "Git.GroupIds", "platform, tools"); err != nil {
+		t.Fatal(err)
+	}
+	if jobs, groups := get("Git.Jobs"), get("Git.GroupIds"); jobs != "4\n" || groups != "platform,tools\n" {
+		t.Errorf("unexpected values %q %q", jobs, groups)
+	}
+
+	// Secrets are masked
+	t.Setenv("MBX_GIT_PRIVATE_TOKEN", "secret")
+	if token := get("Git.PrivateToken"); token != "********\n" {
+		t.Errorf("unexpected token %q", token)
+	}
+
+	if err := runConfig("unset", ConfigUnset, "Git.Jobs"); err != nil {
+		t.Fatal(err)
+	}
+	if jobs := get("Git.Jobs"); jobs != "0\n" {
+		t.Errorf("unexpected value after unset %q", jobs)
+	}
+
+	if err := runConfig("get", ConfigGet, "Git.Unknown"); err == nil {
+		t.Errorf("expected error for unknown key")
+	}
+}
+
+func TestConfigSetRollback(t *testing.T) {
+
+	configFile := newSettingsWorkspace(t, settingsWorkspaceConfig)
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"unknown key", []string{"Git.Unknown", "value"}},
+		{"int", []string{"Git.Jobs", "many"}},
+		{"bool", []string{"Git.NormalizeName", "maybe"}},
+		{"negative jobs", []string{"Git.Jobs", "-1"}},
+		{"type", []string{"Git.Type", "svn"}},
+		{"clone protocol", []string{"Git.CloneProtocol", "ftp"}},
+		{"secret store", []string{"Git.SecretStore", "command"}},
+		{"reserved remote", []string{"Remotes.default.Type", "local"}},
+	}
+
+	for _, test := range tests {
+		if err := runConfig("set", ConfigSet, test.args...); err == nil {
+			t.Errorf("%s : expected invalid value to be rejected", test.name)
+		}
+		// Original content is restored, including comments
+		if content, _ := os.ReadFile(configFile); string(content) != settingsWorkspaceConfig {
+			t.Errorf("%s : unexpected config after rollback %q", test.name, content)
+		}
+	}
+
+	if err := runConfig("unset", ConfigUnset, "Git.BaseUrl"); err == nil {
+		t.Errorf("expected required key to be kept")
+	}
+	if content, _ := os.ReadFile(configFile); string(content) != settingsWorkspaceConfig {
+		t.Errorf("unexpected config after unset rollback %q", content)
+	}
+}
+
+func TestConfigSetRollbackWithoutFile(t *testing.T) {
+
+	configFile := newSettingsWorkspace(t, "")
+
+	// Config file created by rejected update is removed
+	if err := runConfig("set", ConfigSet, "Git.Type", "svn"); err == nil {
+		t.Errorf("expected invalid value to be rejected")
+	}
+	if _, err := os.Stat(configFile); !os.IsNotExist(err) {
+		t.Errorf("config file should be removed on rollback")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+
+	configFile := newSettingsWorkspace(t, settingsWorkspaceConfig)
+	root := t.TempDir()
+
+	if err := runConfig("set", ConfigSet, "Git.BaseUrl", root); err != nil {
+		t.Fatal(err)
+	}
+	if err := runConfig("validate", ConfigValidate); err != nil {
+		t.Errorf("expected valid config, got %v", err)
+	}
+
+	// Invalid content written by hand ( ex: config edit ) is reported, reachability is checked
+	os.WriteFile(configFile, []byte("[Git]\nType = \"local\"\nBaseUrl = \""+filepath.Join(root, "missing")+"\"\nCloneProtocol = \"ftp\"\nAuthMode = \"none\"\n"), 0644) //nolint:errcheck
+	problems := validateConfig(*mustLoad(t), true)
+	if len(problems) != 2 {
+		t.Errorf("unexpected problems %v", problems)
+	}
+	if err := runConfig("validate", ConfigValidate); err == nil {
+		t.Errorf("expected invalid config")
+	}
+
+	// Invalid TOML is reported
+	os.WriteFile(configFile, []byte("[Git\n"), 0644) //nolint:errcheck
+	if err := runConfig("validate", ConfigValidate); err == nil {
+		t.Errorf("expected invalid TOML to be reported")
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/vanroy/microcli/impl/prompt"
 )
 
+const DEFAULT_URL = "https://start.spring.io"
+
 type Initializr struct {
 	config config.Config
 }
@@ -21,6 +23,14 @@ func NewInitializr(config config.Config) *Initializr {
 	}
 }
 
+// Return URL of Initializr, Spring one by default
+func (init *Initializr) Url() string {
+	if init.config.Initializr.Url != "" {
+		return init.config.Initializr.Url
+	}
+	return DEFAULT_URL
+}
+
 func (init *Initializr) Init(projectType string, projectName string, dependencies []string, destFolder string) error {
 
 	// Download archive
@@ -32,15 +42,10 @@ func (init *Initializr) Init(projectType string, projectName string, dependencie
 
 	archive := fmt.Sprintf("/tmp/spring-%s.tgz", projectName)
 
-	url := "https://start.spring.io"
-	if init.config.Initializr.Url != "" {
-		url = init.config.Initializr.Url
-	}
-
 	resp, err := resty.New().R().
 		SetFormData(data).
 		SetOutput(archive).
-		Post(fmt.Sprintf("%s/starter.tgz", url))
+		Post(fmt.Sprintf("%s/starter.tgz", init.Url()))
 
 	if err != nil {
 		prompt.PrintError(resp.String())
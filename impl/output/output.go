@@ -0,0 +1,47 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/vanroy/microcli/impl/config"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	TABLE = "table"
+	JSON  = "json"
+	YAML  = "yaml"
+)
+
+var Formats = []string{TABLE, JSON, YAML}
+
+// Return true if selected output is machine-readable ( JSON / YAML )
+func IsStructured() bool {
+	return config.Options.Output == JSON || config.Options.Output == YAML
+}
+
+// Print records on standard output using selected format
+func Print(records interface{}) error {
+	return Write(os.Stdout, records)
+}
+
+// Write records on given writer using selected format
+func Write(out io.Writer, records interface{}) error {
+
+	switch config.Options.Output {
+	case JSON:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	case YAML:
+		encoder := yaml.NewEncoder(out)
+		encoder.SetIndent(2)
+		defer encoder.Close() //nolint:errcheck
+		return encoder.Encode(records)
+	default:
+		return fmt.Errorf("unsupported output format '%s'", config.Options.Output)
+	}
+}
@@ -3,6 +3,7 @@ package prompt
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
@@ -22,6 +23,16 @@ type Option struct {
 	Name string
 }
 
+// Printer writes decorated messages to a writer, used to buffer output of concurrent tasks
+type Printer struct {
+	out io.Writer
+}
+
+var stdout = NewPrinter(os.Stdout)
+
+// Reader of answers, shared by prompts to not lose buffered answers ( ex: piped on standard input )
+var stdin = bufio.NewReader(os.Stdin)
+
 // Attribute defines a single SGR Code
 type Attribute int
 
@@ -89,33 +100,57 @@ const (
 	BgHiWhite
 )
 
+func NewPrinter(out io.Writer) *Printer {
+	return &Printer{out: out}
+}
+
 func PrintItem(text string) {
+	stdout.PrintItem(text)
+}
+
+func PrintInfo(format string, a ...any) {
+	stdout.PrintInfo(format, a...)
+}
+
+func PrintWarn(format string, a ...any) {
+	stdout.PrintWarn(format, a...)
+}
+
+func PrintError(message string) {
+	stdout.PrintError(message)
+}
+
+func PrintErrorf(format string, a ...any) {
+	stdout.PrintErrorf(format, a...)
+}
+
+func (p *Printer) PrintItem(text string) {
 	if config.Options.Verbose {
-		fmt.Printf("* %s \n", text)
+		fmt.Fprintf(p.out, "* %s \n", text)
 	}
 }
 
-func PrintInfo(format string, a ...any) {
+func (p *Printer) PrintInfo(format string, a ...any) {
 	if config.Options.Verbose {
-		fmt.Printf("%s==> %s%s%s\n", Color(Bold, FgBlue), Color(FgWhite), fmt.Sprintf(format, a...), Color(Reset))
+		fmt.Fprintf(p.out, "%s==> %s%s%s\n", Color(Bold, FgBlue), Color(FgWhite), fmt.Sprintf(format, a...), Color(Reset))
 	}
 }
 
-func PrintWarn(format string, a ...any) {
+func (p *Printer) PrintWarn(format string, a ...any) {
 	if config.Options.Verbose {
-		fmt.Printf("%sWARNING: %s%s%s\n", Color(Bold, FgYellow), Color(FgWhite), fmt.Sprintf(format, a...), Color(Reset))
+		fmt.Fprintf(p.out, "%sWARNING: %s%s%s\n", Color(Bold, FgYellow), Color(FgWhite), fmt.Sprintf(format, a...), Color(Reset))
 	}
 }
 
-func PrintError(message string) {
+func (p *Printer) PrintError(message string) {
 	if config.Options.Verbose {
-		fmt.Printf("%sERROR: %s%s%s\n", Color(Bold, FgRed), Color(FgWhite), message, Color(Reset))
+		fmt.Fprintf(p.out, "%sERROR: %s%s%s\n", Color(Bold, FgRed), Color(FgWhite), message, Color(Reset))
 	}
 }
 
-func PrintErrorf(format string, a ...any) {
+func (p *Printer) PrintErrorf(format string, a ...any) {
 	if config.Options.Verbose {
-		fmt.Printf("%sERROR: %s%s%s\n", Color(Bold, FgRed), Color(FgWhite), fmt.Sprintf(format, a...), Color(Reset))
+		fmt.Fprintf(p.out, "%sERROR: %s%s%s\n", Color(Bold, FgRed), Color(FgWhite), fmt.Sprintf(format, a...), Color(Reset))
 	}
 }
 
@@ -148,10 +183,15 @@ func Input(prompt string, defaultValue ...string) string {
 		fmt.Print(prompt + " ")
 	}
 
-	text, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	text, _ := stdin.ReadString('\n')
 	return strings.Trim(text, "\n")
 }
 
+// Read answers of next prompts from reader, instead of standard input
+func SetInput(in io.Reader) {
+	stdin = bufio.NewReader(in)
+}
+
 func RestrictedInput(prompt string, acceptedValues []string) string {
 	for {
 		input := Input(prompt + " ( " + strings.Join(acceptedValues, " / ") + " ) :")
@@ -192,6 +232,50 @@ func Choice(prompt string, options []Option, defaultValue ...string) string {
 	}
 }
 
+// Select several options, by their numbers separated with space or comma
+func MultiChoice(prompt string, options []Option, defaultValues ...string) []string {
+
+	if len(defaultValues) > 0 {
+		return defaultValues
+	}
+
+	if !config.Options.Interactive {
+		return []string{}
+	}
+
+	if prompt != "" {
+		fmt.Println(prompt)
+	}
+
+	for i := range options {
+		fmt.Printf("%d) %s\n", i+1, options[i].Name)
+	}
+
+	for {
+		str := Input("#? ( separated with space ) ")
+		if strings.TrimSpace(str) == "" {
+			return []string{}
+		}
+
+		var selected []string
+		valid := true
+		for _, item := range strings.FieldsFunc(str, func(r rune) bool { return r == ' ' || r == ',' }) {
+			result, _ := strconv.Atoi(item)
+			if result <= 0 || result > len(options) {
+				valid = false
+				break
+			}
+			if !funk.ContainsString(selected, options[result-1].Id) {
+				selected = append(selected, options[result-1].Id)
+			}
+		}
+
+		if valid {
+			return selected
+		}
+	}
+}
+
 func Password(prompt string) string {
 
 	if !config.Options.Interactive {
@@ -0,0 +1,39 @@
+package secret
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Read secrets from output of a shell command ( ex: pass, op, vault CLI )
+// Key is given to command with MBX_SECRET_HOST, MBX_SECRET_URL and MBX_SECRET_USER variables
+type commandStore struct {
+	command string
+}
+
+func (s *commandStore) Get(key Key) (string, error) {
+
+	cmd := exec.Command("sh", "-c", s.command)
+	cmd.Env = append(os.Environ(), "MBX_SECRET_HOST="+key.Host(), "MBX_SECRET_URL="+key.Url, "MBX_SECRET_USER="+key.User)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	// Secret is the first line, next ones may hold metadata ( ex: pass )
+	secret := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+	if secret == "" {
+		return "", errors.New("command returned an empty secret")
+	}
+
+	return secret, nil
+}
+
+func (s *commandStore) Set(_ Key, _ string) error {
+	return ErrReadOnly
+}
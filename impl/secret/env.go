@@ -0,0 +1,25 @@
+package secret
+
+import (
+	"fmt"
+	"os"
+)
+
+// Read secrets from an environment variable ( ex: provided by CI )
+type envStore struct {
+	name string
+}
+
+func (s *envStore) Get(_ Key) (string, error) {
+
+	value, ok := os.LookupEnv(s.name)
+	if !ok || value == "" {
+		return "", fmt.Errorf("environment variable %s is not defined", s.name)
+	}
+
+	return value, nil
+}
+
+func (s *envStore) Set(_ Key, _ string) error {
+	return ErrReadOnly
+}
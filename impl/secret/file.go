@@ -0,0 +1,169 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"filippo.io/age"
+	"golang.org/x/term"
+)
+
+const PASSPHRASE_ENV = "MBX_SECRET_PASSPHRASE"
+
+// Passphrase read once per process, shared by all file stores
+var passphrase string
+
+// Decrypted secrets by file path, as decryption is slow by design
+var decrypted = map[string]map[string]string{}
+
+// Scrypt work factor ( log2 of cost ) used to encrypt file
+var workFactor = 18
+
+// Store secrets in a file encrypted with a passphrase ( age / scrypt )
+type fileStore struct {
+	path string
+}
+
+func (s *fileStore) Get(key Key) (string, error) {
+
+	secrets, err := s.read()
+	if err != nil {
+		return "", err
+	}
+
+	secret, ok := secrets[fileEntry(key)]
+	if !ok {
+		return "", fmt.Errorf("no secret found for %s in '%s'", key.Host(), s.path)
+	}
+
+	return secret, nil
+}
+
+func (s *fileStore) Set(key Key, secret string) error {
+
+	secrets := map[string]string{}
+	if _, err := os.Stat(s.path); err == nil {
+		if secrets, err = s.read(); err != nil {
+			return err
+		}
+	}
+
+	secrets[fileEntry(key)] = secret
+
+	content, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+
+	pass, err := getPassphrase()
+	if err != nil {
+		return err
+	}
+
+	recipient, err := age.NewScryptRecipient(pass)
+	if err != nil {
+		return err
+	}
+	recipient.SetWorkFactor(workFactor)
+
+	var encrypted bytes.Buffer
+	writer, err := age.Encrypt(&encrypted, recipient)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(content); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(s.path, encrypted.Bytes(), 0600); err != nil {
+		return err
+	}
+
+	decrypted[s.path] = secrets
+	return nil
+}
+
+// Decrypt secrets of file
+func (s *fileStore) read() (map[string]string, error) {
+
+	if secrets, ok := decrypted[s.path]; ok {
+		return secrets, nil
+	}
+
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close() //nolint:errcheck
+
+	pass, err := getPassphrase()
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := age.NewScryptIdentity(pass)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := age.Decrypt(file, identity)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt '%s' ( %s )", s.path, err.Error())
+	}
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := map[string]string{}
+	if err := json.Unmarshal(content, &secrets); err != nil {
+		return nil, err
+	}
+
+	decrypted[s.path] = secrets
+	return secrets, nil
+}
+
+// Return passphrase from environment, or from terminal if interactive
+func getPassphrase() (string, error) {
+
+	if passphrase != "" {
+		return passphrase, nil
+	}
+
+	if value := os.Getenv(PASSPHRASE_ENV); value != "" {
+		passphrase = value
+		return passphrase, nil
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", errors.New("passphrase is required, define " + PASSPHRASE_ENV)
+	}
+
+	fmt.Fprint(os.Stderr, "Enter secrets passphrase : ")
+	value, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+
+	passphrase = string(value)
+	return passphrase, nil
+}
+
+func fileEntry(key Key) string {
+	return key.Host() + "/" + key.User
+}
@@ -0,0 +1,82 @@
+package secret
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Set in environment of git credential, credential helpers started by microbox must not query secret store again
+const GIT_CREDENTIAL_ENV = "MBX_GIT_CREDENTIAL_LOOKUP"
+
+// Store secrets with git credential helper configured by user ( git credential fill / approve )
+type gitCredentialStore struct{}
+
+func (s *gitCredentialStore) Get(key Key) (string, error) {
+
+	out, err := s.credential("fill", key, "")
+	if err != nil {
+		return "", err
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		if password, found := strings.CutPrefix(scanner.Text(), "password="); found && password != "" {
+			return password, nil
+		}
+	}
+
+	return "", errors.New("no credential found for " + key.Host())
+}
+
+func (s *gitCredentialStore) Set(key Key, secret string) error {
+	_, err := s.credential("approve", key, secret)
+	return err
+}
+
+// Execute git credential action, with key ( and password if any ) as input
+func (s *gitCredentialStore) credential(action string, key Key, password string) (string, error) {
+
+	protocol := "https"
+	if parsedUrl, err := url.Parse(key.Url); err == nil && parsedUrl.Scheme != "" {
+		protocol = parsedUrl.Scheme
+	}
+
+	input := fmt.Sprintf("protocol=%s\nhost=%s\nusername=%s\n", protocol, key.Host(), key.User)
+	if password != "" {
+		input += "password=" + password + "\n"
+	}
+
+	cmd := exec.Command("git", "credential", action)
+	// Never prompt user when credential is not found
+	cmd.Env = append(credentialEnv(), "GIT_TERMINAL_PROMPT=0", GIT_CREDENTIAL_ENV+"=1")
+	// Skip repository config, it can contain microbox credential helper
+	cmd.Dir = os.TempDir()
+	cmd.Stdin = strings.NewReader(input + "\n")
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git credential %s failed ( %s )", action, err.Error())
+	}
+
+	return string(out), nil
+}
+
+// Return environment without GIT config given by parent GIT process ( ex: microbox credential helper given with -c )
+func credentialEnv() []string {
+
+	var env []string
+	for _, variable := range os.Environ() {
+		name, _, _ := strings.Cut(variable, "=")
+		if name == "GIT_CONFIG_PARAMETERS" || name == "GIT_CONFIG_COUNT" || strings.HasPrefix(name, "GIT_CONFIG_KEY_") || strings.HasPrefix(name, "GIT_CONFIG_VALUE_") || name == "GIT_DIR" || name == "GIT_WORK_TREE" {
+			continue
+		}
+		env = append(env, variable)
+	}
+
+	return env
+}
@@ -0,0 +1,27 @@
+package secret
+
+import (
+	"errors"
+
+	"github.com/zalando/go-keyring"
+)
+
+// Return error if OS keychain cannot be used ( ex: no secret service on headless Linux )
+func CheckKeyring() error {
+	_, err := keyring.Get("Microbox - check", "check")
+	if err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// Store secrets in OS keychain
+type keyringStore struct{}
+
+func (s *keyringStore) Get(key Key) (string, error) {
+	return keyring.Get(key.Service(), key.User)
+}
+
+func (s *keyringStore) Set(key Key, secret string) error {
+	return keyring.Set(key.Service(), key.User, secret)
+}
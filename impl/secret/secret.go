@@ -0,0 +1,89 @@
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	KEYRING        = "keyring"
+	ENV            = "env"
+	FILE           = "file"
+	COMMAND        = "command"
+	GIT_CREDENTIAL = "git-credential"
+
+	SERVICE_PREFIX = "Microbox - "
+)
+
+var Backends = []string{KEYRING, ENV, FILE, COMMAND, GIT_CREDENTIAL}
+
+var ErrReadOnly = errors.New("secret store is read-only")
+
+// Identify a secret, by URL of service and user name
+type Key struct {
+	Url  string
+	User string
+}
+
+// Settings of secret store
+type Options struct {
+	// Backend name, default to keyring
+	Backend string
+	// Environment variable holding the secret ( env backend )
+	Env string
+	// Encrypted file path ( file backend )
+	File string
+	// Shell command printing the secret ( command backend )
+	Command string
+}
+
+type Store interface {
+	Get(key Key) (string, error)
+	Set(key Key, secret string) error
+}
+
+// Return secret store of backend
+func New(options Options) (Store, error) {
+
+	switch options.Backend {
+	case KEYRING, "":
+		return &keyringStore{}, nil
+	case ENV:
+		if options.Env == "" {
+			return nil, errors.New("environment variable name is required by env secret store")
+		}
+		return &envStore{name: options.Env}, nil
+	case FILE:
+		if options.File == "" {
+			return nil, errors.New("file path is required by file secret store")
+		}
+		return &fileStore{path: options.File}, nil
+	case COMMAND:
+		if options.Command == "" {
+			return nil, errors.New("command is required by command secret store")
+		}
+		return &commandStore{command: options.Command}, nil
+	case GIT_CREDENTIAL:
+		return &gitCredentialStore{}, nil
+	default:
+		return nil, fmt.Errorf("unknown secret store '%s', expected one of %s", options.Backend, strings.Join(Backends, ", "))
+	}
+}
+
+// Return host of key URL, lower cased
+func (k Key) Host() string {
+
+	parsedUrl, err := url.Parse(k.Url)
+	if err != nil {
+		return ""
+	}
+
+	return strings.ToLower(parsedUrl.Host)
+}
+
+// Return service name of key, as registered in keychain ( ex: Microbox - gitlab.com )
+func (k Key) Service() string {
+	return SERVICE_PREFIX + k.Host()
+}
@@ -0,0 +1,94 @@
+package secret
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testKey = Key{Url: "https://gitlab.example.com/api/v4", User: "john"}
+
+func TestFileStore(t *testing.T) {
+
+	t.Setenv(PASSPHRASE_ENV, "passphrase")
+	workFactor = 10
+	t.Cleanup(func() { passphrase = ""; workFactor = 18 })
+
+	path := filepath.Join(t.TempDir(), "secrets.age")
+	store, err := New(Options{Backend: FILE, File: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Set(testKey, "secret"); err != nil {
+		t.Fatalf("Cannot store secret : %s", err.Error())
+	}
+	if err := store.Set(Key{Url: "https://api.github.com", User: "john"}, "other"); err != nil {
+		t.Fatalf("Cannot store secret : %s", err.Error())
+	}
+
+	if secret, err := store.Get(testKey); err != nil || secret != "secret" {
+		t.Errorf("Unexpected secret '%s' ( %v )", secret, err)
+	}
+
+	content, _ := os.ReadFile(path)
+	if len(content) == 0 || string(content) == "secret" {
+		t.Errorf("Secrets file must be encrypted")
+	}
+
+	// Wrong passphrase must not decrypt file
+	decrypted = map[string]map[string]string{}
+	passphrase = "wrong"
+	if _, err := store.Get(testKey); err == nil {
+		t.Errorf("Secret must not be decrypted with wrong passphrase")
+	}
+}
+
+func TestCommandStore(t *testing.T) {
+
+	store, err := New(Options{Backend: COMMAND, Command: `printf "token-for-$MBX_SECRET_HOST\nmetadata"`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if secret, err := store.Get(testKey); err != nil || secret != "token-for-gitlab.example.com" {
+		t.Errorf("Unexpected secret '%s' ( %v )", secret, err)
+	}
+
+	if err := store.Set(testKey, "secret"); err != ErrReadOnly {
+		t.Errorf("Command store must be read-only")
+	}
+}
+
+func TestEnvStore(t *testing.T) {
+
+	t.Setenv("CI_GITLAB_TOKEN", "secret")
+
+	store, err := New(Options{Backend: ENV, Env: "CI_GITLAB_TOKEN"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if secret, err := store.Get(testKey); err != nil || secret != "secret" {
+		t.Errorf("Unexpected secret '%s' ( %v )", secret, err)
+	}
+
+	if _, err := New(Options{Backend: ENV}); err == nil {
+		t.Errorf("Variable name must be required")
+	}
+}
+
+func TestGitCredentialEnv(t *testing.T) {
+
+	t.Setenv("GIT_CONFIG_PARAMETERS", "'credential.helper'='!mbx credential'")
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "credential.helper")
+	t.Setenv("GIT_CONFIG_VALUE_0", "!mbx credential")
+	t.Setenv("GIT_TRACE", "0")
+
+	env := strings.Join(credentialEnv(), "\n")
+	if strings.Contains(env, "GIT_CONFIG") || !strings.Contains(env, "GIT_TRACE=0") {
+		t.Errorf("Unexpected environment %s", env)
+	}
+}
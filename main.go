@@ -2,8 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
+	"strings"
 
+	"github.com/thoas/go-funk"
 	"github.com/urfave/cli/v3"
 
 	"os/signal"
@@ -12,6 +16,7 @@ import (
 	microcli "github.com/vanroy/microcli/impl"
 	"github.com/vanroy/microcli/impl/config"
 	"github.com/vanroy/microcli/impl/git"
+	"github.com/vanroy/microcli/impl/output"
 	pmt "github.com/vanroy/microcli/impl/prompt"
 )
 
@@ -19,8 +24,18 @@ func main() {
 
 	handleSignals()
 
+	// Invoked by GIT as credential helper, standard output is reserved to credential protocol
+	if len(os.Args) > 2 && os.Args[1] == git.CREDENTIAL_COMMAND {
+		if err := git.CredentialHelper(os.Args[2], os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "mbx credential : %s\n", err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// Doctor is kept available to report config errors
 	conf, err := loadConf(context.Background())
-	if err != nil {
+	if err != nil && commandName(os.Args) != "doctor" {
 		pmt.PrintErrorf("Command load config '%s'", err.Error())
 		os.Exit(1)
 	}
@@ -32,7 +47,11 @@ func main() {
 
 	app.Before = initContext
 	app.CommandNotFound = commandNotFound
-	app.Commands = microcli.InitCommands(*conf)
+	if err != nil {
+		app.Commands = microcli.InitRecoveryCommands(err)
+	} else {
+		app.Commands = microcli.InitCommands(*conf)
+	}
 
 	app.Flags = []cli.Flag{
 		&cli.BoolFlag{
@@ -45,9 +64,18 @@ func main() {
 			Aliases: []string{"n"},
 			Usage:   "Non interactive mode",
 		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "Output format of list commands ( " + strings.Join(output.Formats, " | ") + " )",
+			Value:   output.TABLE,
+		},
 	}
 
-	app.Run(context.Background(), os.Args) //nolint:errcheck
+	// Errors are printed by commands, only exit status is set
+	if err := app.Run(context.Background(), os.Args); err != nil {
+		os.Exit(1)
+	}
 }
 
 // Load or init configuration
@@ -61,15 +89,49 @@ func loadConf(context context.Context) (*config.Config, error) {
 		os.Exit(0)
 	}
 
-	return config.Load()
+	conf, err := config.Load()
+	if errors.Is(err, config.ErrSecret) {
+		// Keep running, so secret store settings can be fixed ( config / authenticate commands )
+		pmt.PrintWarn("%s", err.Error())
+		return conf, nil
+	}
+
+	return conf, err
+}
+
+// Return name of command in arguments, global flags are skipped
+func commandName(args []string) string {
+
+	for i := 1; i < len(args); i++ {
+		switch {
+		case args[i] == "-o" || args[i] == "--output":
+			i++
+		case !strings.HasPrefix(args[i], "-"):
+			return args[i]
+		}
+	}
+
+	return ""
 }
 
 // Init context before commands
 func initContext(ctx context.Context, c *cli.Command) (context.Context, error) {
 
+	if !funk.ContainsString(output.Formats, c.String("output")) {
+		pmt.PrintErrorf("Invalid output format '%s'", c.String("output"))
+		return ctx, fmt.Errorf("invalid output format '%s'", c.String("output"))
+	}
+
 	config.Options = config.GlobalOptions{
 		Verbose:     !c.Bool("quiet"),
 		Interactive: !c.Bool("non-interactive"),
+		Output:      c.String("output"),
+	}
+
+	// Keep standard output parsable
+	if output.IsStructured() {
+		config.Options.Verbose = false
+		config.Options.Interactive = false
 	}
 
 	microcli.ShowBanner()
@@ -87,6 +149,8 @@ func handleSignals() {
 	signal.Notify(c, os.Interrupt, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 	go func() {
 		<-c
-		os.Exit(0)
+		// Exit as interrupted by SIGINT, campaign can be resumed from its last saved step
+		git.PrintInterrupted()
+		os.Exit(130)
 	}()
 }